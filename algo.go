@@ -38,14 +38,9 @@ func (g *Graph) VertexNeighbors(key string) (Subgraph, error) {
 
 	// materializa DTO
 	vertices := make([]Vertex, 0, len(verticesSet))
+	index := g.newCauseIndex()
 	for id := range verticesSet {
-		vertices = append(vertices, Vertex{
-			Key:       g.keys[id],
-			Label:     g.labels[id],
-			Class:     g.classLookup[g.classes[id]],
-			Healthy:   g.healthy[id],
-			LastCheck: g.lastCheck[id],
-		})
+		vertices = append(vertices, g.vertex(id, index))
 	}
 
 	edges := make([]Edge, 0, len(edgesSet))
@@ -113,14 +108,9 @@ func (g *Graph) VertexDependencies(key string, all bool) (Subgraph, error) {
 
 	// materializar DTO
 	vertices := make([]Vertex, 0, len(verticesSet))
+	index := g.newCauseIndex()
 	for id := range verticesSet {
-		vertices = append(vertices, Vertex{
-			Key:       g.keys[id],
-			Label:     g.labels[id],
-			Class:     g.classLookup[g.classes[id]],
-			Healthy:   g.healthy[id],
-			LastCheck: g.lastCheck[id],
-		})
+		vertices = append(vertices, g.vertex(id, index))
 	}
 
 	edges := make([]Edge, 0, len(edgesSet))
@@ -188,14 +178,9 @@ func (g *Graph) VertexDependents(key string, all bool) (Subgraph, error) {
 
 	// materializar DTO
 	vertices := make([]Vertex, 0, len(verticesSet))
+	index := g.newCauseIndex()
 	for id := range verticesSet {
-		vertices = append(vertices, Vertex{
-			Key:       g.keys[id],
-			Label:     g.labels[id],
-			Class:     g.classLookup[g.classes[id]],
-			Healthy:   g.healthy[id],
-			LastCheck: g.lastCheck[id],
-		})
+		vertices = append(vertices, g.vertex(id, index))
 	}
 
 	edges := make([]Edge, 0, len(edgesSet))
@@ -262,14 +247,9 @@ func (g *Graph) Path(srcKey, tgtKey string) (Subgraph, error) {
 
 	// materializa DTO
 	outV := make([]Vertex, 0, len(verts))
+	index := g.newCauseIndex()
 	for id := range verts {
-		outV = append(outV, Vertex{
-			Key:       g.keys[id],
-			Label:     g.labels[id],
-			Class:     g.classLookup[g.classes[id]],
-			Healthy:   g.healthy[id],
-			LastCheck: g.lastCheck[id],
-		})
+		outV = append(outV, g.vertex(id, index))
 	}
 	outE := make([]Edge, 0, len(edges))
 	for k := range edges {
@@ -281,3 +261,24 @@ func (g *Graph) Path(srcKey, tgtKey string) (Subgraph, error) {
 	}
 	return Subgraph{Vertices: outV, Edges: outE}, nil
 }
+
+// subgraph materializes the whole graph, for exporters working on Subgraph.
+func (g *Graph) subgraph() Subgraph {
+	outV := make([]Vertex, len(g.labels))
+	index := g.newCauseIndex()
+	for id := range g.labels {
+		outV[id] = g.vertex(id, index)
+	}
+
+	outE := make([]Edge, 0, len(g.dependencies))
+	for src, outs := range g.dependencies {
+		for tgt := range outs {
+			outE = append(outE, Edge{
+				Key:    fmt.Sprintf("%s-%s", g.keys[src], g.keys[tgt]),
+				Source: g.keys[src],
+				Target: g.keys[tgt],
+			})
+		}
+	}
+	return Subgraph{Vertices: outV, Edges: outE}
+}
@@ -0,0 +1,150 @@
+package graphlib
+
+import (
+	"context"
+	"iter"
+	"log/slog"
+	"slices"
+)
+
+// PathOptions limits AllPaths. Zero values mean no limit.
+type PathOptions struct {
+	Context  context.Context // stops the enumeration when done
+	MaxDepth int             // longest path, in edges
+	MaxPaths int             // number of paths yielded
+}
+
+// AllPaths yields every distinct dependency chain from src to tgt as an
+// ordered key slice, src first, in lexicographic order of the keys. The
+// part of the graph the chains can use is copied up front, so the graph is
+// not locked while iterating and later changes are not seen. An unknown key
+// or a cancelled context is yielded as an error, after which the sequence
+// ends. Each yielded slice belongs to the caller.
+func (g *Graph) AllPaths(src, tgt string, opts PathOptions) iter.Seq2[[]string, error] {
+	g.logger.Debug("core.Graph.AllPaths", slog.String("src", src), slog.String("tgt", tgt), slog.Int("max_depth", opts.MaxDepth), slog.Int("max_paths", opts.MaxPaths))
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	g.mu.RLock()
+	adj, dist, err := g.pathsView(src, tgt)
+	g.mu.RUnlock()
+
+	return func(yield func([]string, error) bool) {
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if _, ok := adj[src]; !ok {
+			return
+		}
+
+		type frame struct {
+			key  string
+			next int
+		}
+
+		path := []string{src}
+		stack := []frame{{key: src}}
+		yielded := 0
+
+		for len(stack) > 0 {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			f := &stack[len(stack)-1]
+
+			if f.key == tgt {
+				if !yield(slices.Clone(path), nil) {
+					return
+				}
+				yielded++
+				if opts.MaxPaths > 0 && yielded >= opts.MaxPaths {
+					return
+				}
+			}
+
+			// depth is the number of edges in path so far
+			depth := len(path) - 1
+			next, ok := "", false
+			for !ok && f.next < len(adj[f.key]) {
+				next = adj[f.key][f.next]
+				f.next++
+				ok = opts.MaxDepth <= 0 || depth+1+dist[next] <= opts.MaxDepth
+			}
+
+			if !ok {
+				stack = stack[:len(stack)-1]
+				path = path[:len(path)-1]
+				continue
+			}
+
+			stack = append(stack, frame{key: next})
+			path = append(path, next)
+		}
+	}
+}
+
+// pathsView copies the dependencies of the vertices lying on some path from
+// src to tgt, sorted by key, along with the distance in edges from each of
+// them to tgt.
+func (g *Graph) pathsView(src, tgt string) (map[string][]string, map[string]int, error) {
+	ksrc, ktgt, err := g.lookupPair(src, tgt)
+	if err != nil {
+		g.logger.Error("core.Graph.AllPaths lookup error", slog.String("src", src), slog.String("tgt", tgt), slog.String("err", err.Error()))
+		return nil, nil, err
+	}
+
+	// vertices reaching tgt, by distance
+	dist := map[int]int{ktgt: 0}
+	queue := []int{ktgt}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for d := range g.dependents[v] {
+			if _, seen := dist[d]; !seen {
+				dist[d] = dist[v] + 1
+				queue = append(queue, d)
+			}
+		}
+	}
+
+	adj := make(map[string][]string)
+	distByKey := make(map[string]int)
+	if _, ok := dist[ksrc]; !ok {
+		return adj, distByKey, nil
+	}
+
+	// of those, the ones reachable from src
+	queue = []int{ksrc}
+	seen := map[int]struct{}{ksrc: {}}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+
+		key := g.keys[v]
+		distByKey[key] = dist[v]
+		adj[key] = []string{}
+
+		if v == ktgt {
+			continue
+		}
+		for d := range g.dependencies[v] {
+			if _, ok := dist[d]; !ok {
+				continue
+			}
+			adj[key] = append(adj[key], g.keys[d])
+			if _, ok := seen[d]; !ok {
+				seen[d] = struct{}{}
+				queue = append(queue, d)
+			}
+		}
+		slices.Sort(adj[key])
+	}
+
+	return adj, distByKey, nil
+}
@@ -0,0 +1,79 @@
+package graphlib_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func collectPaths(t *testing.T, g *graphlib.Graph, src, tgt string, opts graphlib.PathOptions) [][]string {
+	t.Helper()
+
+	var out [][]string
+	for path, err := range g.AllPaths(src, tgt, opts) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		out = append(out, path)
+	}
+	return out
+}
+
+func TestAllPaths(t *testing.T) {
+	g := buildGraph()
+	g.AddEdge("A", "D")
+	g.AddEdge("B", "D")
+
+	all := [][]string{{"A", "B", "D", "E"}, {"A", "C", "D", "E"}, {"A", "D", "E"}}
+	if got := collectPaths(t, g, "A", "E", graphlib.PathOptions{}); !reflect.DeepEqual(got, all) {
+		t.Fatalf("want %v, got %v", all, got)
+	}
+
+	if got := collectPaths(t, g, "A", "E", graphlib.PathOptions{MaxDepth: 2}); !reflect.DeepEqual(got, all[2:]) {
+		t.Fatalf("want %v, got %v", all[2:], got)
+	}
+	if got := collectPaths(t, g, "A", "E", graphlib.PathOptions{MaxPaths: 2}); !reflect.DeepEqual(got, all[:2]) {
+		t.Fatalf("want %v, got %v", all[:2], got)
+	}
+
+	if got := collectPaths(t, g, "E", "A", graphlib.PathOptions{}); len(got) != 0 {
+		t.Fatalf("expected no paths, got %v", got)
+	}
+	if got := collectPaths(t, g, "C", "C", graphlib.PathOptions{}); !reflect.DeepEqual(got, [][]string{{"C"}}) {
+		t.Fatalf("expected the single vertex path, got %v", got)
+	}
+
+	// stopping early is fine
+	for range g.AllPaths("A", "E", graphlib.PathOptions{}) {
+		break
+	}
+}
+
+func TestAllPaths_Errors(t *testing.T) {
+	g := buildGraph()
+
+	var nf graphlib.VertexNotFoundErr
+	for _, err := range g.AllPaths("A", "X", graphlib.PathOptions{}) {
+		if !errors.As(err, &nf) {
+			t.Fatalf("expected VertexNotFoundErr, got %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n := 0
+	for _, err := range g.AllPaths("A", "E", graphlib.PathOptions{Context: ctx}) {
+		n++
+		cancel()
+		if n == 2 && !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	}
+	if n != 2 {
+		t.Fatalf("expected a path then the cancellation, got %d items", n)
+	}
+}
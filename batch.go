@@ -0,0 +1,256 @@
+package graphlib
+
+import (
+	"log/slog"
+)
+
+// Tx stages the vertices and edges of a Batch. Nothing reaches the graph
+// before the batch function returns.
+type Tx struct {
+	vertices []txVertex
+	edges    []txEdge
+}
+
+type txVertex struct {
+	key, label, class string
+	status            HealthStatus
+}
+
+type txEdge struct{ src, tgt string }
+
+// AddVertex stages a vertex. Like Graph.AddVertex, it is a no-op for a key
+// that already exists or is already staged.
+func (tx *Tx) AddVertex(key string, label string, class string, healthy bool) {
+	tx.vertices = append(tx.vertices, txVertex{key: key, label: label, class: class, status: statusOf(healthy)})
+}
+
+// AddEdge stages an edge from src to its dependency tgt. Both may be
+// existing or staged vertices.
+func (tx *Tx) AddEdge(src, tgt string) {
+	tx.edges = append(tx.edges, txEdge{src: src, tgt: tgt})
+}
+
+// Batch runs fn to stage additions and then applies all of them under a
+// single lock, or none. Edges go through the same checks as AddEdge, but
+// acyclicity is validated once over the combined result: every staged edge
+// on a cycle is rejected. When anything is rejected the graph is left
+// untouched and a BatchErr lists every rejected operation. An error returned
+// by fn aborts the batch and is returned as is. A WALErr means the batch was
+// applied but did not fully reach the write-ahead log.
+func (g *Graph) Batch(fn func(tx *Tx) error) error {
+	tx := &Tx{}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger.Debug("core.Graph.Batch", slog.Int("vertices", len(tx.vertices)), slog.Int("edges", len(tx.edges)))
+
+	// staged vertices take the ids they will get once added
+	n := len(g.labels)
+	added := make([]txVertex, 0, len(tx.vertices))
+	ids := make(map[string]int, len(tx.vertices))
+	for _, v := range tx.vertices {
+		if _, ok := g.lookup[v.key]; ok {
+			continue
+		}
+		if _, ok := ids[v.key]; ok {
+			continue
+		}
+		ids[v.key] = n + len(added)
+		added = append(added, v)
+	}
+
+	id := func(key string) (int, bool) {
+		if k, ok := g.lookup[key]; ok {
+			return k, true
+		}
+		k, ok := ids[key]
+		return k, ok
+	}
+
+	staged := make(map[int]map[int]struct{}, len(tx.edges))
+	has := func(src, tgt int) bool {
+		if _, ok := g.dependencies[src][tgt]; ok {
+			return true
+		}
+		_, ok := staged[src][tgt]
+		return ok
+	}
+
+	rejected := make(map[int]error)
+	accepted := make([]int, 0, len(tx.edges)) // indexes into tx.edges
+	edges := make([]edgeKey, len(tx.edges))
+
+	for i, e := range tx.edges {
+		src, ok := id(e.src)
+		if !ok {
+			rejected[i] = VertexNotFoundErr{Key: e.src}
+			continue
+		}
+		tgt, ok := id(e.tgt)
+		if !ok {
+			rejected[i] = VertexNotFoundErr{Key: e.tgt}
+			continue
+		}
+
+		switch {
+		case has(src, tgt):
+			continue
+		case has(tgt, src):
+			rejected[i] = BidirectionalEdgeErr{Src: e.src, Tgt: e.tgt}
+			continue
+		case src == tgt:
+			rejected[i] = CycleErr{Src: e.src, Tgt: e.tgt, Path: []string{e.src}}
+			continue
+		}
+
+		if staged[src] == nil {
+			staged[src] = make(map[int]struct{}, 4)
+		}
+		staged[src][tgt] = struct{}{}
+		edges[i] = edgeKey{src, tgt}
+		accepted = append(accepted, i)
+	}
+
+	// the graph is a DAG, so any cycle runs through a staged edge and all
+	// of its vertices end up in the same strongly connected component
+	roots := make([]int, 0, len(staged))
+	for src := range staged {
+		roots = append(roots, src)
+	}
+	neighbors := func(v int, yield func(int)) {
+		for d := range g.dependencies[v] {
+			yield(d)
+		}
+		for d := range staged[v] {
+			yield(d)
+		}
+	}
+	comp := stronglyConnected(roots, neighbors)
+	for _, i := range accepted {
+		e := edges[i]
+		if comp[e.src] != comp[e.tgt] {
+			continue
+		}
+
+		path := make([]string, 0, 8)
+		for _, v := range shortestPath(e.tgt, e.src, neighbors) {
+			if v < n {
+				path = append(path, g.keys[v])
+			} else {
+				path = append(path, added[v-n].key)
+			}
+		}
+		rejected[i] = CycleErr{Src: tx.edges[i].src, Tgt: tx.edges[i].tgt, Path: path}
+	}
+
+	if len(rejected) > 0 {
+		err := BatchErr{Errs: make([]error, 0, len(rejected))}
+		for i := range tx.edges {
+			if e, ok := rejected[i]; ok {
+				err.Errs = append(err.Errs, e)
+			}
+		}
+		g.logger.Error("core.Graph.Batch rejected", slog.Int("rejected", len(rejected)), slog.String("err", err.Error()))
+		return err
+	}
+
+	// the first record that does not reach the log is reported, the
+	// following ones are still attempted
+	var werr error
+	record := func(op walOp, fields ...string) {
+		if err := g.logOp(op, fields...); err != nil && werr == nil {
+			werr = err
+		}
+	}
+
+	for _, v := range added {
+		g.addVertex(v.key, v.label, v.class, v.status)
+		record(opAddVertex, v.key, v.label, v.class, v.status.String())
+	}
+	for _, i := range accepted {
+		g.link(edges[i].src, edges[i].tgt)
+		record(opAddEdge, tx.edges[i].src, tx.edges[i].tgt)
+	}
+
+	// roots holds the distinct sources of the new edges
+	g.refreshHealth("", roots...)
+
+	return werr
+}
+
+// stronglyConnected runs Tarjan's algorithm over the vertices reachable from
+// roots and returns the component of each of them. It is iterative, so deep
+// graphs do not grow the goroutine stack.
+func stronglyConnected(roots []int, neighbors func(v int, yield func(int))) map[int]int {
+	type frame struct {
+		v    int
+		next []int
+	}
+
+	index := make(map[int]int)
+	low := make(map[int]int)
+	comp := make(map[int]int)
+	onStack := make(map[int]bool)
+	stack := make([]int, 0, 16)
+	components := 0
+
+	visit := func(v int) frame {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+
+		f := frame{v: v}
+		neighbors(v, func(d int) { f.next = append(f.next, d) })
+		return f
+	}
+
+	for _, root := range roots {
+		if _, seen := index[root]; seen {
+			continue
+		}
+
+		frames := []frame{visit(root)}
+		for len(frames) > 0 {
+			f := &frames[len(frames)-1]
+
+			if len(f.next) > 0 {
+				d := f.next[0]
+				f.next = f.next[1:]
+
+				if _, seen := index[d]; !seen {
+					frames = append(frames, visit(d))
+				} else if onStack[d] {
+					low[f.v] = min(low[f.v], index[d])
+				}
+				continue
+			}
+
+			v := f.v
+			frames = frames[:len(frames)-1]
+			if len(frames) > 0 {
+				parent := frames[len(frames)-1].v
+				low[parent] = min(low[parent], low[v])
+			}
+
+			if low[v] == index[v] {
+				for {
+					w := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[w] = false
+					comp[w] = components
+					if w == v {
+						break
+					}
+				}
+				components++
+			}
+		}
+	}
+
+	return comp
+}
@@ -0,0 +1,132 @@
+package graphlib_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func TestBatch(t *testing.T) {
+	g := buildGraph()
+
+	err := g.Batch(func(tx *graphlib.Tx) error {
+		tx.AddVertex("G", "gee", "database", true)
+		tx.AddVertex("H", "aitch", "database", false)
+		tx.AddVertex("A", "ignored", "ignored", true)
+		tx.AddEdge("E", "G")
+		tx.AddEdge("G", "H")
+		tx.AddEdge("B", "H")
+		tx.AddEdge("A", "B") // already there
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s := g.Stats(); s.TotalVertices != 8 || s.TotalEdges != 8 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if v, _ := g.GetVertex("A"); v.Class != "server" {
+		t.Fatalf("expected existing vertex to be kept, got %+v", v)
+	}
+	if _, err := g.Path("A", "H"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the new edges pass the failure of H on
+	for _, k := range []string{"A", "B", "E", "G"} {
+		if v, _ := g.GetVertex(k); v.Status != graphlib.StatusUnhealthy || len(v.ImpactedBy) != 1 || v.ImpactedBy[0] != "H" {
+			t.Fatalf("want %s unhealthy impacted by H, got %v %v", k, v.Status, v.ImpactedBy)
+		}
+	}
+}
+
+func TestBatch_Rollback(t *testing.T) {
+	g := buildGraph()
+	seq := g.LastSeq()
+
+	err := g.Batch(func(tx *graphlib.Tx) error {
+		tx.AddVertex("G", "gee", "database", true)
+		tx.AddEdge("E", "G") // fine on its own
+		tx.AddEdge("G", "X") // unknown vertex
+		tx.AddEdge("D", "C") // bidirectional with C → D
+		tx.AddEdge("G", "F") // G → F → D → E → G
+		tx.AddEdge("B", "B") // self loop
+		tx.AddEdge("B", "G") // fine on its own
+		return nil
+	})
+
+	var batch graphlib.BatchErr
+	if !errors.As(err, &batch) {
+		t.Fatalf("expected BatchErr, got %v", err)
+	}
+
+	want := []string{
+		"vertex \"X\" not found",
+		"bidirectional edge D ↔ C not allowed",
+		"edge G → F would create a cycle (F → D → E → G → F)",
+		"edge B → B would create a cycle (B → B)",
+	}
+	// E → G closes the same cycle, only the order of staging tells them apart
+	if len(batch.Errs) != 5 {
+		t.Fatalf("expected 5 errors, got %v", batch.Errs)
+	}
+	if fmt.Sprint(batch.Errs[0]) != "edge E → G would create a cycle (G → F → D → E → G)" {
+		t.Fatalf("unexpected first error %v", batch.Errs[0])
+	}
+	for i, w := range want {
+		if got := batch.Errs[i+1].Error(); got != w {
+			t.Fatalf("error %d: want %q, got %q", i+1, w, got)
+		}
+	}
+
+	var cycle graphlib.CycleErr
+	if !errors.As(err, &cycle) {
+		t.Fatalf("expected CycleErr in %v", err)
+	}
+
+	if _, err := g.GetVertex("G"); err == nil {
+		t.Fatalf("expected staged vertex to be rolled back")
+	}
+	if g.LastSeq() != seq || g.Stats().TotalEdges != 5 {
+		t.Fatalf("rejected batch changed the graph")
+	}
+}
+
+func TestBatch_Abort(t *testing.T) {
+	g := buildGraph()
+	abort := errors.New("abort")
+
+	err := g.Batch(func(tx *graphlib.Tx) error {
+		tx.AddVertex("G", "gee", "database", true)
+		return abort
+	})
+	if err != abort {
+		t.Fatalf("expected abort, got %v", err)
+	}
+	if _, err := g.GetVertex("G"); err == nil {
+		t.Fatalf("expected aborted batch to leave the graph untouched")
+	}
+}
+
+func BenchmarkBatch(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		g := graphlib.NewSoAGraph(nil)
+		err := g.Batch(func(tx *graphlib.Tx) error {
+			for j := 0; j < 5000; j++ {
+				tx.AddVertex(fmt.Sprintf("v%04d", j), "", "server", true)
+			}
+			for j := 0; j < 5000; j++ {
+				for k := 1; k <= 3 && j+7*k < 5000; k++ {
+					tx.AddEdge(fmt.Sprintf("v%04d", j), fmt.Sprintf("v%04d", j+7*k))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package graphlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func buildRandomDAG(n int, seed int64) *Graph {
+	r := rand.New(rand.NewSource(seed))
+	statuses := []HealthStatus{StatusHealthy, StatusHealthy, StatusHealthy, StatusDegraded, StatusUnhealthy, StatusUnknown, StatusMaintenance}
+
+	g := NewSoAGraph(nil)
+	for i := 0; i < n; i++ {
+		k := fmt.Sprintf("v%04d", i)
+		g.AddVertex(k, "label "+k, fmt.Sprintf("class%d", i%7), true)
+	}
+
+	// edges always point to a higher index, so the result is a DAG
+	for i := 0; i < n; i++ {
+		for j := 0; j < 3; j++ {
+			if t := i + 1 + r.Intn(20); t < n {
+				g.AddEdge(fmt.Sprintf("v%04d", i), fmt.Sprintf("v%04d", t))
+			}
+		}
+	}
+
+	for i := 0; i < n; i += 5 {
+		g.SetVertexStatus(fmt.Sprintf("v%04d", i), statuses[r.Intn(len(statuses))])
+	}
+
+	return g
+}
+
+func TestBinarySnapshotRoundTrip(t *testing.T) {
+	g := buildRandomDAG(500, 1)
+	g.RemoveVertex("v0042")
+
+	var bin bytes.Buffer
+	if err := g.WriteBinarySnapshot(&bin); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	loaded, err := LoadBinarySnapshot(&bin)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	var want, got bytes.Buffer
+	g.WriteSnapshot(&want)
+	loaded.WriteSnapshot(&got)
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Fatal("Expected the loaded graph to match the original")
+	}
+
+	if loaded.LastSeq() != 0 {
+		t.Fatalf("Expected an empty change feed, but got seq %d", loaded.LastSeq())
+	}
+
+	// the loaded graph is fully functional
+	loaded.AddVertex("new", "new", "class0", true)
+	if err := loaded.AddEdge("new", "v0001"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+}
+
+func TestBinarySnapshotCorruption(t *testing.T) {
+	g := buildRandomDAG(50, 2)
+
+	var buf bytes.Buffer
+	g.WriteBinarySnapshot(&buf)
+	data := buf.Bytes()
+
+	for name, corrupt := range map[string][]byte{
+		"magic":     append([]byte("XXXX"), data[4:]...),
+		"version":   append(append([]byte{}, data[:4]...), append([]byte{9, 9}, data[6:]...)...),
+		"truncated": data[:len(data)/2],
+		"checksum":  append(append([]byte{}, data[:len(data)-1]...), data[len(data)-1]^0xff),
+		"payload": func() []byte {
+			d := append([]byte{}, data...)
+			d[len(d)/2] ^= 0x01
+			return d
+		}(),
+		"empty": nil,
+	} {
+		_, err := LoadBinarySnapshot(bytes.NewReader(corrupt))
+		var sErr SnapshotErr
+		if !errors.As(err, &sErr) {
+			t.Fatalf("%s: expected SnapshotErr, got %v", name, err)
+		}
+	}
+}
+
+func TestBinarySnapshotCycle(t *testing.T) {
+	for name, edges := range map[string][][2]string{
+		"cycle":         {{"A", "B"}, {"B", "C"}, {"C", "A"}, {"D", "A"}},
+		"bidirectional": {{"D", "A"}, {"A", "B"}, {"B", "A"}},
+	} {
+		g := NewSoAGraph(nil)
+		for _, k := range []string{"A", "B", "C", "D"} {
+			g.AddVertex(k, k, "server", true)
+		}
+		// bypass the checks of AddEdge to write an invalid snapshot
+		for _, e := range edges {
+			g.link(g.lookup[e[0]], g.lookup[e[1]])
+		}
+
+		var buf bytes.Buffer
+		g.WriteBinarySnapshot(&buf)
+
+		_, err := LoadBinarySnapshot(&buf)
+		switch name {
+		case "cycle":
+			var cErr CycleErr
+			if !errors.As(err, &cErr) {
+				t.Fatalf("%s: expected CycleErr, got %v", name, err)
+			}
+		case "bidirectional":
+			var bErr BidirectionalEdgeErr
+			if !errors.As(err, &bErr) {
+				t.Fatalf("%s: expected BidirectionalEdgeErr, got %v", name, err)
+			}
+		}
+	}
+}
+
+func BenchmarkBinarySnapshot(b *testing.B) {
+	g := buildRandomDAG(5000, 3)
+
+	var buf bytes.Buffer
+	g.WriteBinarySnapshot(&buf)
+	data := buf.Bytes()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := LoadBinarySnapshot(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
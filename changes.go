@@ -0,0 +1,158 @@
+package graphlib
+
+import (
+	"log/slog"
+	"sort"
+)
+
+type ChangeType uint8
+
+const (
+	VertexAdded ChangeType = iota + 1
+	VertexRemoved
+	EdgeAdded
+	EdgeRemoved
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case VertexAdded:
+		return "vertex_added"
+	case VertexRemoved:
+		return "vertex_removed"
+	case EdgeAdded:
+		return "edge_added"
+	case EdgeRemoved:
+		return "edge_removed"
+	}
+	return "invalid"
+}
+
+const defaultChangeRetention = 1024
+
+// ChangeEvent describes a topology change. Vertex changes fill Key, Label
+// and Class, edge changes fill Src and Tgt. Seq increases by one with every
+// change made to the graph.
+type ChangeEvent struct {
+	Seq   uint64
+	Type  ChangeType
+	Key   string
+	Label string
+	Class string
+	Src   string
+	Tgt   string
+	Time  int64
+}
+
+// SetChangeRetention sets how many past changes are kept for consumers
+// resuming with Changes or WatchChanges.
+func (g *Graph) SetChangeRetention(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger.Debug("core.Graph.SetChangeRetention", slog.Int("retention", n))
+
+	if n < 0 {
+		n = 0
+	}
+
+	g.changeKeep = n
+	g.trimChanges()
+}
+
+// LastSeq returns the sequence number of the latest change, zero when the
+// graph never changed.
+func (g *Graph) LastSeq() uint64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.changeSeq
+}
+
+// Changes returns the changes that happened after since, oldest first. It
+// fails with ChangeLogTruncatedErr when some of them are no longer retained.
+func (g *Graph) Changes(since uint64) ([]ChangeEvent, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.Changes", slog.Uint64("since", since))
+
+	return g.changesSince(since)
+}
+
+// WatchChanges replays the changes that happened after since and then
+// streams new ones. A consumer that falls more than buffer changes behind
+// is disconnected: its channel is closed and it can resume from the last
+// sequence number it received.
+func (g *Graph) WatchChanges(since uint64, buffer int) (<-chan ChangeEvent, func(), error) {
+	// the read lock keeps changes from being published between the replay
+	// and the subscription
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.WatchChanges", slog.Uint64("since", since), slog.Int("buffer", buffer))
+
+	backlog, err := g.changesSince(since)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+
+	ch, cancel := g.changeHub.subscribe(buffer+len(backlog), OverflowDisconnect, nil, backlog...)
+
+	return ch, cancel, nil
+}
+
+func (g *Graph) changesSince(since uint64) ([]ChangeEvent, error) {
+	if since > g.changeSeq {
+		since = g.changeSeq
+	}
+
+	oldest := g.changeSeq - uint64(len(g.changes)) // last change no longer retained
+	if since < oldest {
+		err := ChangeLogTruncatedErr{Since: since, Oldest: oldest + 1}
+		g.logger.Error("core.Graph.Changes log truncated", slog.Uint64("since", since), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	i := sort.Search(len(g.changes), func(i int) bool { return g.changes[i].Seq > since })
+	out := make([]ChangeEvent, len(g.changes)-i)
+	copy(out, g.changes[i:])
+
+	return out, nil
+}
+
+func (g *Graph) recordChange(ev ChangeEvent) {
+	g.changeSeq++
+	ev.Seq = g.changeSeq
+	ev.Time = g.nowFn()
+
+	if g.changeKeep > 0 {
+		g.changes = append(g.changes, ev)
+		if len(g.changes) >= 2*g.changeKeep {
+			g.trimChanges()
+		}
+	}
+
+	if g.changeHub.active() {
+		g.changeHub.publish(ev)
+	}
+}
+
+func (g *Graph) resetChanges() {
+	g.changes = nil
+	g.changeSeq = 0
+}
+
+func (g *Graph) trimChanges() {
+	if len(g.changes) <= g.changeKeep {
+		return
+	}
+
+	kept := make([]ChangeEvent, g.changeKeep, 2*g.changeKeep)
+	copy(kept, g.changes[len(g.changes)-g.changeKeep:])
+	g.changes = kept
+}
@@ -0,0 +1,127 @@
+package graphlib_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func TestChanges(t *testing.T) {
+	g := graphlib.NewSoAGraph(nil)
+
+	g.AddVertex("A", "a", "server", true)
+	g.AddVertex("B", "b", "database", true)
+	g.AddVertex("A", "a", "server", true)
+	g.AddEdge("A", "B")
+	g.AddEdge("A", "B")
+	g.RemoveVertex("B")
+
+	changes, err := g.Changes(0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []graphlib.ChangeEvent{
+		{Seq: 1, Type: graphlib.VertexAdded, Key: "A", Label: "a", Class: "server"},
+		{Seq: 2, Type: graphlib.VertexAdded, Key: "B", Label: "b", Class: "database"},
+		{Seq: 3, Type: graphlib.EdgeAdded, Src: "A", Tgt: "B"},
+		{Seq: 4, Type: graphlib.EdgeRemoved, Src: "A", Tgt: "B"},
+		{Seq: 5, Type: graphlib.VertexRemoved, Key: "B", Label: "b", Class: "database"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("want %d changes, got %v", len(want), changes)
+	}
+	for i := range want {
+		changes[i].Time = 0
+		if changes[i] != want[i] {
+			t.Fatalf("change %d: want %+v, got %+v", i, want[i], changes[i])
+		}
+	}
+
+	changes, _ = g.Changes(3)
+	if len(changes) != 2 || changes[0].Seq != 4 {
+		t.Fatalf("expected to resume after seq 3, got %v", changes)
+	}
+
+	if seq := g.LastSeq(); seq != 5 {
+		t.Fatalf("want last seq 5, got %d", seq)
+	}
+}
+
+func TestChanges_Truncated(t *testing.T) {
+	g := graphlib.NewSoAGraph(nil)
+	g.SetChangeRetention(2)
+
+	for _, k := range []string{"A", "B", "C", "D", "E"} {
+		g.AddVertex(k, k, "server", true)
+	}
+
+	_, err := g.Changes(1)
+	var tErr graphlib.ChangeLogTruncatedErr
+	if !errors.As(err, &tErr) {
+		t.Fatalf("expected ChangeLogTruncatedErr, got %v", err)
+	}
+
+	changes, err := g.Changes(3)
+	if err != nil || len(changes) != 2 || changes[0].Key != "D" {
+		t.Fatalf("expected D and E, got %v, err %v", changes, err)
+	}
+}
+
+func TestWatchChanges(t *testing.T) {
+	g := graphlib.NewSoAGraph(nil)
+	g.AddVertex("A", "A", "server", true)
+	g.AddVertex("B", "B", "server", true)
+
+	ch, cancel, err := g.WatchChanges(1, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cancel()
+
+	g.AddEdge("A", "B")
+	g.ReplaceEdges("A", nil)
+
+	var got []graphlib.ChangeEvent
+	for i := 0; i < 3; i++ {
+		got = append(got, <-ch)
+	}
+
+	if got[0].Key != "B" || got[1].Type != graphlib.EdgeAdded || got[2].Type != graphlib.EdgeRemoved {
+		t.Fatalf("unexpected changes %v", got)
+	}
+	for i, ev := range got {
+		if ev.Seq != uint64(i+2) {
+			t.Fatalf("expected consecutive sequence numbers, got %v", got)
+		}
+	}
+}
+
+func TestWatchChanges_SlowConsumer(t *testing.T) {
+	g := graphlib.NewSoAGraph(nil)
+
+	ch, _, err := g.WatchChanges(0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	g.AddVertex("A", "A", "server", true)
+	g.AddVertex("B", "B", "server", true)
+
+	last := (<-ch).Seq
+	if _, ok := <-ch; ok {
+		t.Fatal("expected a slow consumer to be disconnected")
+	}
+
+	// resume where the consumer left off
+	ch, cancel, err := g.WatchChanges(last, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cancel()
+
+	if ev := <-ch; ev.Key != "B" {
+		t.Fatalf("expected to resume with B, got %+v", ev)
+	}
+}
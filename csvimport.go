@@ -0,0 +1,209 @@
+package graphlib
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// CSVVertexColumns names the header columns holding each vertex field.
+// Only Key is required in the file: a missing label or class is empty and a
+// missing or empty healthy column means healthy.
+type CSVVertexColumns struct {
+	Key     string
+	Label   string
+	Class   string
+	Healthy string
+}
+
+// CSVEdgeColumns names the header columns holding the source and the target
+// of each edge. The source depends on the target.
+type CSVEdgeColumns struct {
+	Src string
+	Tgt string
+}
+
+type CSVOptions struct {
+	Vertices CSVVertexColumns // empty names default to key, label, class and healthy
+	Edges    CSVEdgeColumns   // empty names default to src and tgt
+	Comma    rune             // field delimiter, ',' when zero
+
+	// DryRun validates every row against a copy of the graph and leaves the
+	// graph untouched.
+	DryRun bool
+}
+
+// CSVRowErr is the error of one row of an import.
+type CSVRowErr struct {
+	File string // "vertices" or "edges"
+	Line int
+	Err  error
+}
+
+func (e CSVRowErr) Error() string {
+	return fmt.Sprintf("%s line %d: %v", e.File, e.Line, e.Err)
+}
+
+func (e CSVRowErr) Unwrap() error {
+	return e.Err
+}
+
+// CSVReport summarizes an import. Vertices and Edges count the rows that
+// were applied, or would have been in a dry run.
+type CSVReport struct {
+	Vertices int
+	Edges    int
+	Errors   []CSVRowErr
+}
+
+// ImportCSV streams the vertices and then the edges files into the graph,
+// row by row through AddVertex and AddEdge, so rows applied before a bad one
+// stay applied. Invalid rows, unknown vertices, cycles and bidirectional
+// edges are collected in the report. Either reader may be nil. The returned
+// error is only set when a file cannot be read or lacks a required column.
+func (g *Graph) ImportCSV(vertices, edges io.Reader, opts CSVOptions) (CSVReport, error) {
+	g.logger.Debug("core.Graph.ImportCSV", slog.Bool("dry_run", opts.DryRun))
+
+	target := g
+	if opts.DryRun {
+		g.mu.RLock()
+		c, err := g.clone()
+		g.mu.RUnlock()
+		if err != nil {
+			return CSVReport{}, err
+		}
+		target = c
+	}
+
+	cols := opts.Vertices
+	setDefault(&cols.Key, "key")
+	setDefault(&cols.Label, "label")
+	setDefault(&cols.Class, "class")
+	setDefault(&cols.Healthy, "healthy")
+
+	ecols := opts.Edges
+	setDefault(&ecols.Src, "src")
+	setDefault(&ecols.Tgt, "tgt")
+
+	var report CSVReport
+	var err error
+
+	if vertices != nil {
+		report.Vertices, err = readCSV(vertices, opts.Comma, "vertices", []string{cols.Key}, []string{cols.Label, cols.Class, cols.Healthy}, &report,
+			func(f []string) error {
+				if f[0] == "" {
+					return ImportErr{Format: "csv", Reason: "empty key"}
+				}
+
+				healthy := true
+				if f[3] != "" {
+					b, err := strconv.ParseBool(strings.TrimSpace(f[3]))
+					if err != nil {
+						return ImportErr{Format: "csv", Reason: fmt.Sprintf("invalid healthy value %q", f[3])}
+					}
+					healthy = b
+				}
+
+				target.AddVertex(f[0], f[1], f[2], healthy)
+				return nil
+			})
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if edges != nil {
+		report.Edges, err = readCSV(edges, opts.Comma, "edges", []string{ecols.Src, ecols.Tgt}, nil, &report,
+			func(f []string) error {
+				return target.AddEdge(f[0], f[1])
+			})
+		if err != nil {
+			return report, err
+		}
+	}
+
+	g.logger.Info("core.Graph.ImportCSV done", slog.Bool("dry_run", opts.DryRun), slog.Int("vertices", report.Vertices), slog.Int("edges", report.Edges), slog.Int("errors", len(report.Errors)))
+
+	return report, nil
+}
+
+// readCSV calls row with the fields of every record, in the order of
+// required followed by optional, and returns how many rows it accepted.
+// Optional columns missing from the header are empty. Rows that cannot be
+// parsed or that row rejects are added to the report.
+func readCSV(r io.Reader, comma rune, file string, required, optional []string, report *CSVReport, row func(fields []string) error) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	if comma != 0 {
+		cr.Comma = comma
+	}
+
+	header, err := cr.Read()
+	if err != nil {
+		return 0, ImportErr{Format: "csv", Reason: fmt.Sprintf("%s header: %v", file, err)}
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+
+	columns := make([]int, 0, len(required)+len(optional))
+	for _, name := range required {
+		i, ok := index[name]
+		if !ok {
+			return 0, ImportErr{Format: "csv", Reason: fmt.Sprintf("%s header has no %q column", file, name)}
+		}
+		columns = append(columns, i)
+	}
+	for _, name := range optional {
+		i, ok := index[name]
+		if !ok {
+			i = -1
+		}
+		columns = append(columns, i)
+	}
+
+	accepted := 0
+	fields := make([]string, len(columns))
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return accepted, nil
+		}
+
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			report.Errors = append(report.Errors, CSVRowErr{File: file, Line: perr.Line, Err: perr.Err})
+			continue
+		}
+		if err != nil {
+			return accepted, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		for i, c := range columns {
+			fields[i] = ""
+			if c >= 0 && c < len(record) {
+				fields[i] = record[c]
+			}
+		}
+
+		if err := row(fields); err != nil {
+			report.Errors = append(report.Errors, CSVRowErr{File: file, Line: line, Err: err})
+			continue
+		}
+		accepted++
+	}
+}
+
+func setDefault(s *string, def string) {
+	if *s == "" {
+		*s = def
+	}
+}
@@ -0,0 +1,117 @@
+package graphlib_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+const csvVertices = `key,label,class,healthy
+app,App,service,true
+db,Database,database,false
+cache,Cache,database,
+,Nameless,service,true
+queue,Queue,service,maybe
+`
+
+const csvEdges = `src,tgt
+app,db
+app,cache
+db,app
+app,ghost
+cache,db
+db,cache
+"broken,db
+`
+
+func TestImportCSV(t *testing.T) {
+	g := graphlib.NewSoAGraph(nil)
+
+	report, err := g.ImportCSV(strings.NewReader(csvVertices), strings.NewReader(csvEdges), graphlib.CSVOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Vertices != 3 || report.Edges != 3 {
+		t.Fatalf("expected 3 vertices and 3 edges, got %+v", report)
+	}
+
+	type row struct {
+		file string
+		line int
+	}
+	var got []row
+	for _, e := range report.Errors {
+		got = append(got, row{e.File, e.Line})
+	}
+	want := []row{{"vertices", 5}, {"vertices", 6}, {"edges", 4}, {"edges", 5}, {"edges", 7}, {"edges", 8}}
+	if len(got) != len(want) {
+		t.Fatalf("expected errors %v, got %v", want, report.Errors)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected errors %v, got %v", want, report.Errors)
+		}
+	}
+
+	var bidi graphlib.BidirectionalEdgeErr
+	var missing graphlib.VertexNotFoundErr
+	var cycle graphlib.CycleErr
+	if !errors.As(report.Errors[2], &bidi) || !errors.As(report.Errors[3], &missing) || !errors.As(report.Errors[4], &bidi) || errors.As(report.Errors[4], &cycle) {
+		t.Fatalf("unexpected errors %v", report.Errors)
+	}
+
+	if v, err := g.GetVertex("db"); err != nil || v.Label != "Database" || v.OwnHealthy {
+		t.Fatalf("unexpected vertex %+v, %v", v, err)
+	}
+	if v, _ := g.GetVertex("cache"); !v.OwnHealthy {
+		t.Fatalf("expected empty healthy column to mean healthy, got %+v", v)
+	}
+	if s := g.Stats(); s.TotalVertices != 3 || s.TotalEdges != 3 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestImportCSV_DryRun(t *testing.T) {
+	g := buildGraph()
+	before := g.LastSeq()
+
+	edges := "from;to\nE;A\nA;E\n"
+	report, err := g.ImportCSV(nil, strings.NewReader(edges), graphlib.CSVOptions{
+		Edges:  graphlib.CSVEdgeColumns{Src: "from", Tgt: "to"},
+		Comma:  ';',
+		DryRun: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var cycle graphlib.CycleErr
+	if report.Edges != 1 || len(report.Errors) != 1 || !errors.As(report.Errors[0], &cycle) {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	if g.LastSeq() != before || g.Stats().TotalEdges != 5 {
+		t.Fatalf("dry run changed the graph")
+	}
+}
+
+func TestImportCSV_MissingColumn(t *testing.T) {
+	g := graphlib.NewSoAGraph(nil)
+
+	_, err := g.ImportCSV(strings.NewReader("id,label\nA,a\n"), nil, graphlib.CSVOptions{})
+	var imp graphlib.ImportErr
+	if !errors.As(err, &imp) {
+		t.Fatalf("expected ImportErr, got %v", err)
+	}
+
+	report, err := g.ImportCSV(strings.NewReader("id,label\nA,a\n"), nil, graphlib.CSVOptions{Vertices: graphlib.CSVVertexColumns{Key: "id"}})
+	if err != nil || report.Vertices != 1 {
+		t.Fatalf("unexpected result %+v, %v", report, err)
+	}
+	if v, _ := g.GetVertex("A"); v.Label != "a" {
+		t.Fatalf("unexpected vertex %+v", v)
+	}
+}
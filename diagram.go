@@ -0,0 +1,117 @@
+package graphlib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MermaidOptions configures WriteMermaid.
+type MermaidOptions struct {
+	Direction string // flowchart direction such as "LR", "TD" when empty
+
+	// GroupByClass draws the vertices of each class in a subgraph.
+	GroupByClass bool
+}
+
+// WriteMermaid writes sg as a Mermaid flowchart. Each vertex gets the CSS
+// class named after its effective status, declared with the colours of the
+// other exporters. Keys are turned into safe node ids and labels are
+// escaped, so any key can be exported.
+func WriteMermaid(w io.Writer, sg Subgraph, opts MermaidOptions) error {
+	sg = sorted(sg)
+
+	dir := opts.Direction
+	if dir == "" {
+		dir = "TD"
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "flowchart %s\n", dir)
+
+	if opts.GroupByClass {
+		for _, class := range classesOf(sg.Vertices) {
+			fmt.Fprintf(bw, "    subgraph %s[\"%s\"]\n", classID(class), mermaidEscape(class))
+			for _, v := range sg.Vertices {
+				if v.Class == class {
+					writeMermaidVertex(bw, "        ", v)
+				}
+			}
+			fmt.Fprintf(bw, "    end\n")
+		}
+	} else {
+		for _, v := range sg.Vertices {
+			writeMermaidVertex(bw, "    ", v)
+		}
+	}
+
+	for _, e := range sg.Edges {
+		fmt.Fprintf(bw, "    %s --> %s\n", diagramID(e.Source), diagramID(e.Target))
+	}
+
+	for _, s := range statusOrder {
+		fmt.Fprintf(bw, "    classDef %s fill:%s,stroke:#424242\n", s, statusColors[s])
+	}
+
+	return bw.Flush()
+}
+
+func writeMermaidVertex(w io.Writer, indent string, v Vertex) {
+	fmt.Fprintf(w, "%s%s[\"%s\"]:::%s\n", indent, diagramID(v.Key), mermaidEscape(vertexLabel(v)), v.Status)
+}
+
+// mermaidEscape replaces the characters that end or break a quoted label
+// with Mermaid entity codes.
+var mermaidEscape = strings.NewReplacer(
+	`"`, "#quot;",
+	"#", "#35;",
+	"<", "#lt;",
+	">", "#gt;",
+	"\n", " ",
+	"\r", "",
+).Replace
+
+// PlantUMLOptions configures WritePlantUML.
+type PlantUMLOptions struct {
+	Title string // diagram title, omitted when empty
+}
+
+// WritePlantUML writes sg as a PlantUML component diagram. Components carry
+// their class as stereotype and are filled with the colour of their
+// effective status. Edges point from a vertex to its dependency.
+func WritePlantUML(w io.Writer, sg Subgraph, opts PlantUMLOptions) error {
+	sg = sorted(sg)
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "@startuml\n")
+	if opts.Title != "" {
+		fmt.Fprintf(bw, "title %s\n", plantUMLEscape(opts.Title))
+	}
+
+	for _, v := range sg.Vertices {
+		fmt.Fprintf(bw, "component \"%s\" as %s <<%s>> %s\n",
+			plantUMLEscape(vertexLabel(v)), diagramID(v.Key), plantUMLEscape(v.Class), statusColors[v.Status])
+	}
+
+	for _, e := range sg.Edges {
+		fmt.Fprintf(bw, "%s --> %s\n", diagramID(e.Source), diagramID(e.Target))
+	}
+
+	fmt.Fprintf(bw, "@enduml\n")
+
+	return bw.Flush()
+}
+
+// plantUMLEscape writes the characters that end a quoted name or a
+// stereotype as PlantUML unicode escapes.
+var plantUMLEscape = strings.NewReplacer(
+	`"`, "<U+0022>",
+	`\`, "<U+005C>",
+	"<", "<U+003C>",
+	">", "<U+003E>",
+	"\n", `\n`,
+	"\r", "",
+).Replace
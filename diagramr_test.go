@@ -0,0 +1,96 @@
+package graphlib_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func diagramGraph() *graphlib.Graph {
+	g := graphlib.NewSoAGraph(nil)
+	g.AddVertex("web-1", "Web <1>", "service", true)
+	g.AddVertex("db_main", `Main "DB"`, "database", true)
+	g.AddVertex("end", "", "database", true)
+	g.AddEdge("web-1", "db_main")
+	g.AddEdge("web-1", "end")
+	g.SetVertexStatus("db_main", graphlib.StatusDegraded)
+	return g
+}
+
+func TestWriteMermaid(t *testing.T) {
+	sg, err := diagramGraph().VertexNeighbors("web-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := graphlib.WriteMermaid(&buf, sg, graphlib.MermaidOptions{Direction: "LR"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `flowchart LR
+    n_db_5fmain["Main #quot;DB#quot;"]:::degraded
+    n_end["end"]:::healthy
+    n_web_2d1["Web #lt;1#gt;"]:::degraded
+    n_web_2d1 --> n_db_5fmain
+    n_web_2d1 --> n_end
+    classDef healthy fill:#a5d6a7,stroke:#424242
+    classDef degraded fill:#ffe082,stroke:#424242
+    classDef unhealthy fill:#ef9a9a,stroke:#424242
+    classDef unknown fill:#e0e0e0,stroke:#424242
+    classDef maintenance fill:#90caf9,stroke:#424242
+`
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	graphlib.WriteMermaid(&buf, sg, graphlib.MermaidOptions{GroupByClass: true})
+	for _, want := range []string{"flowchart TD\n", `subgraph c_database["database"]`, "        n_end[\"end\"]:::healthy\n", "    end\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, buf.String())
+		}
+	}
+
+	// a key spelled like a group never shares its id
+	g := graphlib.NewSoAGraph(nil)
+	g.AddVertex("class:db", "", "db", true)
+	g.AddVertex("db", "", "db", true)
+	g.AddEdge("class:db", "db")
+	sg, _ = g.VertexNeighbors("db")
+
+	buf.Reset()
+	graphlib.WriteMermaid(&buf, sg, graphlib.MermaidOptions{GroupByClass: true})
+	for _, want := range []string{`subgraph c_db["db"]`, "        n_class_3adb[\"class:db\"]:::healthy\n", "    n_class_3adb --> n_db\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestWritePlantUML(t *testing.T) {
+	sg, err := diagramGraph().VertexDependencies("web-1", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := graphlib.WritePlantUML(&buf, sg, graphlib.PlantUMLOptions{Title: "web deps"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `@startuml
+title web deps
+component "Main <U+0022>DB<U+0022>" as n_db_5fmain <<database>> #ffe082
+component "end" as n_end <<database>> #a5d6a7
+component "Web <U+003C>1<U+003E>" as n_web_2d1 <<service>> #ffe082
+n_web_2d1 --> n_db_5fmain
+n_web_2d1 --> n_end
+@enduml
+`
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,107 @@
+package graphlib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// DOTOptions configures WriteDOT.
+type DOTOptions struct {
+	Name    string // graph name, "graph" when empty
+	RankDir string // Graphviz rankdir such as "LR", left to Graphviz when empty
+
+	// ClusterByClass groups the vertices of each class in a cluster.
+	// Otherwise classes are told apart by shape.
+	ClusterByClass bool
+	// ClassShapes overrides the shape picked for a class.
+	ClassShapes map[string]string
+}
+
+var dotShapes = []string{"box", "ellipse", "cylinder", "hexagon", "component", "note", "octagon", "tab"}
+
+// WriteDOT writes sg as a Graphviz digraph. Edges point from a vertex to its
+// dependency and vertices are filled with the colour of their effective
+// status, with a dashed border when the status is inherited. The output is
+// sorted by key.
+func WriteDOT(w io.Writer, sg Subgraph, opts DOTOptions) error {
+	sg = sorted(sg)
+
+	name := opts.Name
+	if name == "" {
+		name = "graph"
+	}
+
+	shapes := make(map[string]string)
+	for i, class := range classesOf(sg.Vertices) {
+		shape, ok := opts.ClassShapes[class]
+		if !ok {
+			shape = dotShapes[i%len(dotShapes)]
+		}
+		shapes[class] = shape
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "digraph %s {\n", dotQuote(name))
+	if opts.RankDir != "" {
+		fmt.Fprintf(bw, "\trankdir=%s;\n", dotQuote(opts.RankDir))
+	}
+	fmt.Fprintf(bw, "\tnode [style=filled];\n")
+
+	if opts.ClusterByClass {
+		for _, class := range classesOf(sg.Vertices) {
+			fmt.Fprintf(bw, "\tsubgraph %s {\n", dotQuote("cluster_"+class))
+			fmt.Fprintf(bw, "\t\tlabel=%s;\n", dotQuote(class))
+			for _, v := range sg.Vertices {
+				if v.Class == class {
+					writeDOTVertex(bw, "\t\t", v, shapes[class])
+				}
+			}
+			fmt.Fprintf(bw, "\t}\n")
+		}
+	} else {
+		for _, v := range sg.Vertices {
+			writeDOTVertex(bw, "\t", v, shapes[v.Class])
+		}
+	}
+
+	for _, e := range sg.Edges {
+		fmt.Fprintf(bw, "\t%s -> %s;\n", dotQuote(e.Source), dotQuote(e.Target))
+	}
+
+	fmt.Fprintf(bw, "}\n")
+
+	return bw.Flush()
+}
+
+// WriteDOT writes the whole graph with the package level WriteDOT.
+func (g *Graph) WriteDOT(w io.Writer, opts DOTOptions) error {
+	g.mu.RLock()
+	sg := g.subgraph()
+	g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.WriteDOT", slog.Int("vertices", len(sg.Vertices)), slog.Int("edges", len(sg.Edges)))
+
+	return WriteDOT(w, sg, opts)
+}
+
+func writeDOTVertex(w io.Writer, indent string, v Vertex, shape string) {
+	style := "filled"
+	if v.Status != v.OwnStatus {
+		style = "filled,dashed"
+	}
+
+	fmt.Fprintf(w, "%s%s [label=%s, shape=%s, style=%s, fillcolor=%s, tooltip=%s];\n",
+		indent, dotQuote(v.Key), dotQuote(vertexLabel(v)), dotQuote(shape), dotQuote(style),
+		dotQuote(statusColors[v.Status]), dotQuote(v.Status.String()))
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+
+// dotQuote returns s as a quoted DOT identifier.
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
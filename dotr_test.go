@@ -0,0 +1,82 @@
+package graphlib_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func TestWriteDOT(t *testing.T) {
+	g := graphlib.NewSoAGraph(nil)
+	g.AddVertex("web", "Web", "service", true)
+	g.AddVertex("db", "Main \"DB\"", "database", true)
+	g.AddVertex("cache", "", "database", true)
+	g.AddEdge("web", "db")
+	g.AddEdge("web", "cache")
+	g.SetVertexStatus("db", graphlib.StatusUnhealthy)
+
+	sg, err := g.VertexDependencies("web", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := graphlib.WriteDOT(&buf, sg, graphlib.DOTOptions{Name: "deps", RankDir: "LR"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `digraph "deps" {
+	rankdir="LR";
+	node [style=filled];
+	"cache" [label="cache", shape="box", style="filled", fillcolor="#a5d6a7", tooltip="healthy"];
+	"db" [label="Main \"DB\"", shape="box", style="filled", fillcolor="#ef9a9a", tooltip="unhealthy"];
+	"web" [label="Web", shape="ellipse", style="filled,dashed", fillcolor="#ef9a9a", tooltip="unhealthy"];
+	"web" -> "cache";
+	"web" -> "db";
+}
+`
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+
+	// the same subgraph in another order writes the same bytes
+	for i, j := 0, len(sg.Vertices)-1; i < j; i, j = i+1, j-1 {
+		sg.Vertices[i], sg.Vertices[j] = sg.Vertices[j], sg.Vertices[i]
+	}
+	var again bytes.Buffer
+	graphlib.WriteDOT(&again, sg, graphlib.DOTOptions{Name: "deps", RankDir: "LR"})
+	if again.String() != want {
+		t.Fatalf("output is not deterministic:\n%s", again.String())
+	}
+}
+
+func TestWriteDOT_Clusters(t *testing.T) {
+	g := buildGraph()
+	g.AddVertex("G", "gee", "database", true)
+	g.AddEdge("E", "G")
+
+	var buf bytes.Buffer
+	if err := g.WriteDOT(&buf, graphlib.DOTOptions{ClusterByClass: true, ClassShapes: map[string]string{"database": "cylinder"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`digraph "graph" {`,
+		`subgraph "cluster_database" {`,
+		`label="database";`,
+		`"G" [label="gee", shape="cylinder"`,
+		`subgraph "cluster_server" {`,
+		`"E" -> "G";`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	if n := strings.Count(out, " -> "); n != 6 {
+		t.Fatalf("expected 6 edges, got %d:\n%s", n, out)
+	}
+}
@@ -1,11 +1,15 @@
 package graphlib
 
 type Vertex struct {
-	Key       string
-	Label     string
-	Class     string
-	Healthy   bool
-	LastCheck int64
+	Key        string
+	Label      string
+	Class      string
+	Healthy    bool         // Status == StatusHealthy
+	OwnHealthy bool         // OwnStatus == StatusHealthy
+	Status     HealthStatus // effective status, including what is inherited from dependencies
+	OwnStatus  HealthStatus // status reported by the vertex itself
+	ImpactedBy []string     // failing dependencies that reported the failure themselves
+	LastCheck  int64
 }
 
 type Edge struct {
@@ -20,10 +24,42 @@ type Subgraph struct {
 }
 
 type Stats struct {
-	TotalVertices          int
-	TotalUnhealthyVertices int
-	TotalEdges             int
-	TotalHealthyVertices   int
+	TotalVertices            int
+	TotalUnhealthyVertices   int
+	TotalEdges               int
+	TotalHealthyVertices     int
+	TotalDegradedVertices    int
+	TotalUnknownVertices     int
+	TotalMaintenanceVertices int
+	TotalStaleVertices       int
 
 	UnhealthyVertices []Vertex
+	StaleVertices     []Vertex
+}
+
+type RootCause struct {
+	Cause    Vertex
+	Impacted []Vertex
+}
+
+// Traversal is the Subgraph found by a depth-limited traversal, with the
+// number of hops from the root at which each vertex was found.
+type Traversal struct {
+	Subgraph
+	Depth map[string]int
+}
+
+// ImpactReport describes what marking Sources unhealthy would do. Vertices
+// carry the statuses they would have.
+type ImpactReport struct {
+	Sources  []Vertex
+	NotFound []string // requested keys missing from the graph
+
+	Affected map[string][]Vertex // vertices whose effective status would change, by class
+	Counts   map[string]int      // len(Affected[class])
+	Total    int
+
+	// Chain is the longest chain of affected vertices, from a source to
+	// the vertex farthest from it.
+	Chain []string
 }
@@ -1,6 +1,9 @@
 package graphlib
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type VertexNotFoundErr struct {
 	Key string
@@ -18,13 +21,25 @@ func (e BidirectionalEdgeErr) Error() string {
 	return fmt.Sprintf("bidirectional edge %s ↔ %s not allowed", e.Src, e.Tgt)
 }
 
+type EdgeNotFoundErr struct {
+	Src, Tgt string
+}
+
+func (e EdgeNotFoundErr) Error() string {
+	return fmt.Sprintf("edge %s → %s not found", e.Src, e.Tgt)
+}
+
 type CycleErr struct {
-	Src string
-	Tgt string
+	Src  string
+	Tgt  string
+	Path []string // existing path from Tgt to Src
 }
 
 func (e CycleErr) Error() string {
-	return fmt.Sprintf("edge %s → %s would create a cycle", e.Src, e.Tgt)
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("edge %s → %s would create a cycle", e.Src, e.Tgt)
+	}
+	return fmt.Sprintf("edge %s → %s would create a cycle (%s → %s)", e.Src, e.Tgt, strings.Join(e.Path, " → "), e.Tgt)
 }
 
 type VertexPathErr struct {
@@ -35,3 +50,71 @@ type VertexPathErr struct {
 func (e VertexPathErr) Error() string {
 	return fmt.Sprintf("no path from %s to %s", e.Src, e.Dst)
 }
+
+type InvalidHealthStatusErr struct {
+	Status string
+}
+
+func (e InvalidHealthStatusErr) Error() string {
+	return fmt.Sprintf("invalid health status %q", e.Status)
+}
+
+type InvalidPolicyErr struct {
+	Reason string
+}
+
+func (e InvalidPolicyErr) Error() string {
+	return fmt.Sprintf("invalid propagation policy: %s", e.Reason)
+}
+
+type ChangeLogTruncatedErr struct {
+	Since  uint64
+	Oldest uint64
+}
+
+func (e ChangeLogTruncatedErr) Error() string {
+	return fmt.Sprintf("changes after %d are no longer retained, oldest available is %d", e.Since, e.Oldest)
+}
+
+type SnapshotErr struct {
+	Reason string
+}
+
+func (e SnapshotErr) Error() string {
+	return fmt.Sprintf("invalid snapshot: %s", e.Reason)
+}
+
+type WALErr struct {
+	Reason string
+}
+
+func (e WALErr) Error() string {
+	return fmt.Sprintf("write-ahead log: %s", e.Reason)
+}
+
+type ImportErr struct {
+	Format string
+	Reason string
+}
+
+func (e ImportErr) Error() string {
+	return fmt.Sprintf("invalid %s input: %s", e.Format, e.Reason)
+}
+
+// BatchErr lists the operations a Batch rejected, in the order they were
+// staged.
+type BatchErr struct {
+	Errs []error
+}
+
+func (e BatchErr) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("batch rejected, %d operations failed: %s", len(e.Errs), strings.Join(msgs, "; "))
+}
+
+func (e BatchErr) Unwrap() []error {
+	return e.Errs
+}
@@ -0,0 +1,194 @@
+package graphlib
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// OverflowPolicy decides what happens to an event when the buffer of a
+// subscriber is full. Publishing never blocks the graph.
+type OverflowPolicy uint8
+
+const (
+	// OverflowDropOldest discards the oldest buffered event to make room.
+	OverflowDropOldest OverflowPolicy = iota
+	// OverflowDropNewest discards the event being published.
+	OverflowDropNewest
+	// OverflowDisconnect closes the channel of the subscriber.
+	OverflowDisconnect
+)
+
+const defaultEventBuffer = 64
+
+type HealthEvent struct {
+	Key   string
+	Class string
+	Old   HealthStatus
+	New   HealthStatus
+	Cause string // vertex whose report triggered the change, empty for graph-wide changes
+	Time  int64
+}
+
+// HealthFilter selects the events delivered to a subscriber. Empty fields
+// match everything. Buffer and Overflow configure the channel returned by
+// Subscribe.
+type HealthFilter struct {
+	Keys     []string
+	Classes  []string
+	Statuses []HealthStatus // matched against the new status
+
+	Buffer   int
+	Overflow OverflowPolicy
+}
+
+func (f HealthFilter) match() func(HealthEvent) bool {
+	keys := toSet(f.Keys)
+	classes := toSet(f.Classes)
+	statuses := toSet(f.Statuses)
+
+	return func(ev HealthEvent) bool {
+		if keys != nil {
+			if _, ok := keys[ev.Key]; !ok {
+				return false
+			}
+		}
+		if classes != nil {
+			if _, ok := classes[ev.Class]; !ok {
+				return false
+			}
+		}
+		if statuses != nil {
+			if _, ok := statuses[ev.New]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Subscribe returns a channel receiving every change of effective status
+// that matches filter, whether it comes from SetVertexStatus, propagation,
+// ClearHealthyStatus or the stale sweeper. cancel closes the channel.
+func (g *Graph) Subscribe(filter HealthFilter) (<-chan HealthEvent, func()) {
+	g.logger.Debug("core.Graph.Subscribe", slog.Any("keys", filter.Keys), slog.Any("classes", filter.Classes))
+
+	return g.healthHub.subscribe(filter.Buffer, filter.Overflow, filter.match())
+}
+
+func (g *Graph) emitHealth(v int, old, new HealthStatus, cause string) {
+	if !g.healthHub.active() {
+		return
+	}
+
+	g.healthHub.publish(HealthEvent{
+		Key:   g.keys[v],
+		Class: g.classLookup[g.classes[v]],
+		Old:   old,
+		New:   new,
+		Cause: cause,
+		Time:  g.nowFn(),
+	})
+}
+
+type subscriber[T any] struct {
+	ch       chan T
+	match    func(T) bool
+	overflow OverflowPolicy
+}
+
+// hub fans events out to subscribers without ever blocking the publisher.
+// It has its own lock, so cancelling a subscription never waits for the
+// graph mutex.
+type hub[T any] struct {
+	mu   sync.Mutex
+	next uint64
+	subs map[uint64]*subscriber[T]
+}
+
+// subscribe registers a subscriber. backlog is queued on the channel before
+// any published event, so buffer must leave room for it.
+func (h *hub[T]) subscribe(buffer int, overflow OverflowPolicy, match func(T) bool, backlog ...T) (<-chan T, func()) {
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subs == nil {
+		h.subs = make(map[uint64]*subscriber[T], 4)
+	}
+
+	id := h.next
+	h.next++
+
+	sub := &subscriber[T]{ch: make(chan T, buffer), match: match, overflow: overflow}
+	for _, ev := range backlog {
+		sub.ch <- ev
+	}
+	h.subs[id] = sub
+
+	return sub.ch, func() { h.remove(id) }
+}
+
+func (h *hub[T]) remove(id uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if sub, ok := h.subs[id]; ok {
+		close(sub.ch)
+		delete(h.subs, id)
+	}
+}
+
+func (h *hub[T]) active() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subs) > 0
+}
+
+func (h *hub[T]) publish(ev T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, sub := range h.subs {
+		if sub.match != nil && !sub.match(ev) {
+			continue
+		}
+
+		select {
+		case sub.ch <- ev:
+			continue
+		default:
+		}
+
+		switch sub.overflow {
+		case OverflowDropOldest:
+			select {
+			case <-sub.ch:
+			default:
+			}
+			select {
+			case sub.ch <- ev:
+			default:
+			}
+		case OverflowDisconnect:
+			close(sub.ch)
+			delete(h.subs, id)
+		}
+	}
+}
+
+func toSet[T comparable](items []T) map[T]struct{} {
+	if len(items) == 0 {
+		return nil
+	}
+
+	set := make(map[T]struct{}, len(items))
+	for _, it := range items {
+		set[it] = struct{}{}
+	}
+
+	return set
+}
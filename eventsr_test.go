@@ -0,0 +1,172 @@
+package graphlib_test
+
+import (
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func drain(ch <-chan graphlib.HealthEvent) []graphlib.HealthEvent {
+	var out []graphlib.HealthEvent
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return out
+			}
+			out = append(out, ev)
+		default:
+			return out
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	g := buildGraph()
+
+	ch, cancel := g.Subscribe(graphlib.HealthFilter{})
+	defer cancel()
+
+	g.SetVertexHealth("D", false)
+
+	evs := drain(ch)
+	got := make(map[string]graphlib.HealthEvent, len(evs))
+	for _, ev := range evs {
+		got[ev.Key] = ev
+	}
+
+	for _, k := range []string{"A", "C", "D", "F"} {
+		ev, ok := got[k]
+		if !ok {
+			t.Fatalf("expected an event for %s, got %v", k, evs)
+		}
+		if ev.Old != graphlib.StatusHealthy || ev.New != graphlib.StatusUnhealthy || ev.Cause != "D" || ev.Class != "server" {
+			t.Fatalf("unexpected event %+v", ev)
+		}
+	}
+	if len(evs) != 4 {
+		t.Fatalf("expected 4 events, got %v", evs)
+	}
+
+	// no effective change, no event
+	g.SetVertexHealth("C", false)
+	if evs := drain(ch); len(evs) != 0 {
+		t.Fatalf("expected no events, got %v", evs)
+	}
+
+	g.ClearHealthyStatus()
+	evs = drain(ch)
+	if len(evs) != 4 {
+		t.Fatalf("expected 4 events, got %v", evs)
+	}
+	for _, ev := range evs {
+		if ev.New != graphlib.StatusHealthy || ev.Cause != "" {
+			t.Fatalf("unexpected event %+v", ev)
+		}
+	}
+}
+
+func TestSubscribe_Filter(t *testing.T) {
+	g := buildGraph()
+
+	ch, cancel := g.Subscribe(graphlib.HealthFilter{
+		Keys:     []string{"A", "F"},
+		Statuses: []graphlib.HealthStatus{graphlib.StatusHealthy},
+	})
+	defer cancel()
+
+	g.SetVertexHealth("D", false)
+	if evs := drain(ch); len(evs) != 0 {
+		t.Fatalf("expected no events, got %v", evs)
+	}
+
+	g.SetVertexHealth("D", true)
+	evs := drain(ch)
+	if len(evs) != 2 {
+		t.Fatalf("expected 2 events, got %v", evs)
+	}
+	for _, ev := range evs {
+		if ev.Key != "A" && ev.Key != "F" {
+			t.Fatalf("unexpected event %+v", ev)
+		}
+	}
+}
+
+func TestSubscribe_Diamond(t *testing.T) {
+	// D fails only when both X and Y do, and Y itself depends on X, so D
+	// may be evaluated before Y changes; it must still end up unhealthy
+	// with a single event. Map order varies, so try a few graphs.
+	for i := 0; i < 20; i++ {
+		g := graphlib.NewSoAGraph(nil)
+		g.AddVertex("X", "X", "db", true)
+		g.AddVertex("Y", "Y", "app", true)
+		g.AddVertex("D", "D", "web", true)
+		g.AddEdge("Y", "X")
+		g.AddEdge("D", "X")
+		g.AddEdge("D", "Y")
+		g.SetVertexPolicy("D", graphlib.PropagationPolicy{Mode: graphlib.PolicyAll})
+
+		ch, cancel := g.Subscribe(graphlib.HealthFilter{})
+		g.SetVertexHealth("X", false)
+		evs := drain(ch)
+		cancel()
+
+		if len(evs) != 3 {
+			t.Fatalf("expected 3 events, got %v", evs)
+		}
+		if v, _ := g.GetVertex("D"); v.Status != graphlib.StatusUnhealthy {
+			t.Fatalf("want D unhealthy, got %v", v.Status)
+		}
+	}
+}
+
+func TestSubscribe_Overflow(t *testing.T) {
+	g := buildGraph()
+
+	oldest, cancelOldest := g.Subscribe(graphlib.HealthFilter{Keys: []string{"E"}, Buffer: 2, Overflow: graphlib.OverflowDropOldest})
+	defer cancelOldest()
+	newest, cancelNewest := g.Subscribe(graphlib.HealthFilter{Keys: []string{"E"}, Buffer: 2, Overflow: graphlib.OverflowDropNewest})
+	defer cancelNewest()
+	disconnect, _ := g.Subscribe(graphlib.HealthFilter{Keys: []string{"E"}, Buffer: 2, Overflow: graphlib.OverflowDisconnect})
+
+	statuses := []graphlib.HealthStatus{
+		graphlib.StatusDegraded,
+		graphlib.StatusUnhealthy,
+		graphlib.StatusMaintenance,
+	}
+	for _, s := range statuses {
+		g.SetVertexStatus("E", s)
+	}
+
+	evs := drain(oldest)
+	if len(evs) != 2 || evs[0].New != graphlib.StatusUnhealthy || evs[1].New != graphlib.StatusMaintenance {
+		t.Fatalf("expected the two newest events, got %v", evs)
+	}
+
+	evs = drain(newest)
+	if len(evs) != 2 || evs[0].New != graphlib.StatusDegraded || evs[1].New != graphlib.StatusUnhealthy {
+		t.Fatalf("expected the two oldest events, got %v", evs)
+	}
+
+	evs = drain(disconnect)
+	if len(evs) != 2 {
+		t.Fatalf("expected the two buffered events, got %v", evs)
+	}
+	if _, ok := <-disconnect; ok {
+		t.Fatal("expected the channel to be closed")
+	}
+}
+
+func TestSubscribe_Cancel(t *testing.T) {
+	g := buildGraph()
+
+	ch, cancel := g.Subscribe(graphlib.HealthFilter{})
+	cancel()
+	cancel()
+
+	g.SetVertexHealth("E", false)
+
+	if _, ok := <-ch; ok {
+		t.Fatal("expected the channel to be closed")
+	}
+}
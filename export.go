@@ -0,0 +1,90 @@
+package graphlib
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// statusColors are the fill colours exporters use for each effective status.
+var statusColors = map[HealthStatus]string{
+	StatusHealthy:     "#a5d6a7",
+	StatusDegraded:    "#ffe082",
+	StatusUnhealthy:   "#ef9a9a",
+	StatusUnknown:     "#e0e0e0",
+	StatusMaintenance: "#90caf9",
+}
+
+// sorted returns a copy of sg with vertices ordered by key and edges by
+// source then target, so exporters write the same output for the same
+// subgraph.
+func sorted(sg Subgraph) Subgraph {
+	out := Subgraph{
+		Vertices: slices.Clone(sg.Vertices),
+		Edges:    slices.Clone(sg.Edges),
+	}
+
+	slices.SortFunc(out.Vertices, func(a, b Vertex) int { return strings.Compare(a.Key, b.Key) })
+	slices.SortFunc(out.Edges, func(a, b Edge) int {
+		if c := strings.Compare(a.Source, b.Source); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Target, b.Target)
+	})
+
+	return out
+}
+
+// classesOf returns the distinct classes of vertices, sorted.
+func classesOf(vertices []Vertex) []string {
+	classes := make([]string, 0, 8)
+	for _, v := range vertices {
+		classes = append(classes, v.Class)
+	}
+	slices.Sort(classes)
+
+	return slices.Compact(classes)
+}
+
+// vertexLabel is the text exporters show for v, its key when it has no
+// label.
+func vertexLabel(v Vertex) string {
+	if v.Label == "" {
+		return v.Key
+	}
+	return v.Label
+}
+
+// diagramID turns key into an identifier made of letters, digits and
+// underscores. Every other byte, underscores included, is written as _xx in
+// hex so distinct keys never collide, and the prefix keeps keys such as
+// "end" from clashing with keywords.
+func diagramID(key string) string {
+	return escapeID("n_", key)
+}
+
+// classID is the identifier of the group drawn for class. Its prefix is
+// never produced by diagramID, so it cannot collide with any vertex.
+func classID(class string) string {
+	return escapeID("c_", class)
+}
+
+func escapeID(prefix, s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + len(prefix))
+	b.WriteString(prefix)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "_%02x", c)
+	}
+
+	return b.String()
+}
+
+// statusOrder lists every status in the order exporters declare them.
+var statusOrder = []HealthStatus{StatusHealthy, StatusDegraded, StatusUnhealthy, StatusUnknown, StatusMaintenance}
@@ -0,0 +1,65 @@
+package graphlib_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func TestGEXFRoundTrip(t *testing.T) {
+	g := interchangeGraph()
+
+	var buf bytes.Buffer
+	if err := g.WriteGEXF(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`<gexf xmlns="http://gexf.net/1.3" version="1.3">`,
+		`<graph defaultedgetype="directed" mode="static">`,
+		`<attribute id="class" title="class" type="string"></attribute>`,
+		`<attvalue for="status" value="degraded"></attvalue>`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, buf.String())
+		}
+	}
+
+	loaded, err := graphlib.ReadGEXF(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	equalGraphs(t, g, loaded)
+}
+
+func TestReadGEXF_Invalid(t *testing.T) {
+	doc := `<gexf version="1.2">
+  <graph defaultedgetype="directed">
+    <attributes class="node"><attribute id="0" title="status" type="string"/></attributes>
+    <nodes>
+      <node id="A" label="a"><attvalues><attvalue for="0" value="broken"/></attvalues></node>
+      <node id="B"/><node id="C"/><node id="C"/>
+    </nodes>
+    <edges>
+      <edge id="0" source="B" target="C"/>
+      <edge id="1" source="C" target="B"/>
+      <edge id="2" source="B" target="C" type="undirected"/>
+    </edges>
+  </graph>
+</gexf>`
+
+	_, err := graphlib.ReadGEXF(strings.NewReader(doc))
+
+	var status graphlib.InvalidHealthStatusErr
+	var bidi graphlib.BidirectionalEdgeErr
+	var imp graphlib.ImportErr
+	if !errors.As(err, &status) || !errors.As(err, &bidi) || !errors.As(err, &imp) {
+		t.Fatalf("expected status, bidirectional and import errors, got %v", err)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 4 {
+		t.Fatalf("expected 4 errors, got %d: %v", n, err)
+	}
+}
@@ -3,6 +3,7 @@ package graphlib
 import (
 	"log/slog"
 	"os"
+	"slices"
 	"sync"
 	"time"
 )
@@ -10,15 +11,27 @@ import (
 type Graph struct {
 	labels       []string
 	classes      []int
-	healthy      []bool
+	status       []HealthStatus
+	ownStatus    []HealthStatus
 	lastCheck    []int64
 	keys         map[int]string
 	lookup       map[string]int
 	dependents   map[int]map[int]struct{}
 	dependencies map[int]map[int]struct{}
 	classLookup  map[int]string
+	propagation  map[HealthStatus]HealthStatus
+	classPolicy  map[int]PropagationPolicy
+	vertexPolicy map[int]PropagationPolicy
+	staleTTL     time.Duration
+	classTTL     map[int]time.Duration
 	nowFn        func() int64
 	logger       *slog.Logger
+	healthHub    hub[HealthEvent]
+	changes      []ChangeEvent
+	changeSeq    uint64
+	changeKeep   int
+	changeHub    hub[ChangeEvent]
+	wal          *wal
 	mu           sync.RWMutex
 }
 
@@ -30,15 +43,21 @@ func NewSoAGraph(logger *slog.Logger) *Graph {
 	g := &Graph{
 		labels:       make([]string, 0, 1000),
 		classes:      make([]int, 0, 1000),
-		healthy:      make([]bool, 0, 1000),
+		status:       make([]HealthStatus, 0, 1000),
+		ownStatus:    make([]HealthStatus, 0, 1000),
 		lastCheck:    make([]int64, 0, 1000),
 		keys:         make(map[int]string, 1000),
 		lookup:       make(map[string]int, 1000),
 		dependents:   make(map[int]map[int]struct{}, 1000),
 		dependencies: make(map[int]map[int]struct{}, 1000),
 		classLookup:  make(map[int]string, 1000),
+		propagation:  defaultPropagation(),
+		classPolicy:  make(map[int]PropagationPolicy, 8),
+		vertexPolicy: make(map[int]PropagationPolicy, 8),
+		classTTL:     make(map[int]time.Duration, 8),
 		nowFn:        func() int64 { return time.Now().UnixNano() },
 		logger:       logger,
+		changeKeep:   defaultChangeRetention,
 		mu:           sync.RWMutex{},
 	}
 
@@ -51,9 +70,20 @@ func (g *Graph) AddVertex(key string, label string, class string, healthy bool)
 
 	g.logger.Debug("core.Graph.AddVertex", slog.String("key", key), slog.String("label", label), slog.Bool("healthy", healthy))
 
+	n := len(g.labels)
+	g.addVertex(key, label, class, statusOf(healthy))
+
+	if len(g.labels) > n {
+		g.logOp(opAddVertex, key, label, class, statusOf(healthy).String())
+	}
+}
+
+// addVertex creates the vertex unless key already exists, and returns its
+// index either way.
+func (g *Graph) addVertex(key string, label string, class string, status HealthStatus) int {
 	if k, ok := g.lookup[key]; ok {
 		g.logger.Debug("core.Graph.AddVertex lookup found vertex", slog.String("key", key), slog.Int("id", k))
-		return
+		return k
 	}
 
 	idx := len(g.labels)
@@ -62,22 +92,17 @@ func (g *Graph) AddVertex(key string, label string, class string, healthy bool)
 	g.keys[idx] = key
 	g.lookup[key] = idx
 
-	var cix = len(g.classLookup)
-
-	for i := range g.classLookup {
-		if g.classLookup[i] == class {
-			cix = i
-			g.logger.Debug("core.Graph.AddVertex class already exists", slog.String("key", key))
-			break
-		}
-	}
-
-	g.classLookup[cix] = class
+	cix := g.classIndex(class)
 
 	g.labels = append(g.labels, label)
 	g.classes = append(g.classes, cix)
-	g.healthy = append(g.healthy, healthy)
+	g.status = append(g.status, status)
+	g.ownStatus = append(g.ownStatus, status)
 	g.lastCheck = append(g.lastCheck, g.nowFn())
+
+	g.recordChange(ChangeEvent{Type: VertexAdded, Key: key, Label: label, Class: class})
+
+	return idx
 }
 
 func (g *Graph) AddEdge(src, tgt string) error {
@@ -86,18 +111,45 @@ func (g *Graph) AddEdge(src, tgt string) error {
 
 	g.logger.Debug("core.Graph.AddEdge", slog.String("src", src), slog.String("tgt", tgt))
 
+	if err := g.addEdge(src, tgt); err != nil {
+		return err
+	}
+
+	return g.logOp(opAddEdge, src, tgt)
+}
+
+func (g *Graph) addEdge(src, tgt string) error {
+	ksrc, ktgt, exists, err := g.checkEdge(src, tgt)
+	if err != nil || exists {
+		return err
+	}
+
+	g.logger.Debug("core.Graph.AddEdge Edge will be created", slog.String("src", src), slog.String("tgt", tgt))
+
+	g.link(ksrc, ktgt)
+
+	// only src gains a dependency; its dependents are visited only when
+	// its status changes
+	g.refreshHealth(tgt, ksrc)
+
+	return nil
+}
+
+// checkEdge validates a new edge from src to tgt and returns the indexes of
+// both ends, and whether the edge is already there.
+func (g *Graph) checkEdge(src, tgt string) (ksrc, ktgt int, exists bool, err error) {
 	ksrc, ok := g.lookup[src]
 	if !ok {
-		err := VertexNotFoundErr{Key: src}
+		err = VertexNotFoundErr{Key: src}
 		g.logger.Error("core.Graph.AddEdge src lookup error", slog.String("key", src), slog.String("err", err.Error()))
-		return err
+		return 0, 0, false, err
 	}
 
-	ktgt, ok := g.lookup[tgt]
+	ktgt, ok = g.lookup[tgt]
 	if !ok {
-		err := VertexNotFoundErr{Key: tgt}
+		err = VertexNotFoundErr{Key: tgt}
 		g.logger.Error("core.Graph.AddEdge tgt lookup error", slog.String("key", tgt), slog.String("err", err.Error()))
-		return err
+		return 0, 0, false, err
 	}
 
 	g.logger.Debug("core.Graph.AddEdge src and tgt lookup success", slog.String("src", src), slog.String("tgt", tgt))
@@ -105,35 +157,198 @@ func (g *Graph) AddEdge(src, tgt string) error {
 	// prevent edge multiplicity
 	if g.exists(ksrc, ktgt) {
 		g.logger.Info("core.Graph.AddEdge already exists", slog.String("src", src), slog.String("tgt", tgt))
-		return nil
+		return ksrc, ktgt, true, nil
 	}
 
 	// prevent bidirectional edges
 	if g.exists(ktgt, ksrc) {
-		err := BidirectionalEdgeErr{Src: src, Tgt: tgt}
+		err = BidirectionalEdgeErr{Src: src, Tgt: tgt}
 		g.logger.Error("core.Graph.AddEdge will cause a bidirectional relation", slog.String("src", src), slog.String("tgt", tgt), slog.String("err", err.Error()))
-		return err
+		return 0, 0, false, err
 	}
 
 	// prevent cycles
-	if g.wouldCreateCycle(ksrc, ktgt) {
-		err := CycleErr{Src: src, Tgt: tgt}
+	if path := g.wouldCreateCycle(ksrc, ktgt); path != nil {
+		err = CycleErr{Src: src, Tgt: tgt, Path: g.keysOf(path)}
 		g.logger.Error("core.Graph.AddEdge will cause a cycle", slog.String("src", src), slog.String("tgt", tgt), slog.String("err", err.Error()))
+		return 0, 0, false, err
+	}
+
+	return ksrc, ktgt, false, nil
+}
+
+func (g *Graph) RemoveEdge(src, tgt string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger.Debug("core.Graph.RemoveEdge", slog.String("src", src), slog.String("tgt", tgt))
+
+	if err := g.removeEdge(src, tgt); err != nil {
 		return err
 	}
 
-	if g.dependencies[ksrc] == nil {
-		g.dependencies[ksrc] = make(map[int]struct{}, 4)
+	return g.logOp(opRemoveEdge, src, tgt)
+}
+
+func (g *Graph) removeEdge(src, tgt string) error {
+	ksrc, ok := g.lookup[src]
+	if !ok {
+		err := VertexNotFoundErr{Key: src}
+		g.logger.Error("core.Graph.RemoveEdge src lookup error", slog.String("key", src), slog.String("err", err.Error()))
+		return err
 	}
 
-	if g.dependents[ktgt] == nil {
-		g.dependents[ktgt] = make(map[int]struct{}, 4)
+	ktgt, ok := g.lookup[tgt]
+	if !ok {
+		err := VertexNotFoundErr{Key: tgt}
+		g.logger.Error("core.Graph.RemoveEdge tgt lookup error", slog.String("key", tgt), slog.String("err", err.Error()))
+		return err
 	}
 
-	g.logger.Debug("core.Graph.AddEdge Edge will be created", slog.String("src", src), slog.String("tgt", tgt))
+	if !g.exists(ksrc, ktgt) {
+		err := EdgeNotFoundErr{Src: src, Tgt: tgt}
+		g.logger.Error("core.Graph.RemoveEdge edge lookup error", slog.String("src", src), slog.String("tgt", tgt), slog.String("err", err.Error()))
+		return err
+	}
 
-	g.dependencies[ksrc][ktgt] = struct{}{}
-	g.dependents[ktgt][ksrc] = struct{}{}
+	g.unlink(ksrc, ktgt)
+	g.refreshHealth(tgt, ksrc)
+
+	return nil
+}
+
+// ReplaceEdges swaps all outgoing edges of src for edges to targets. Either
+// every new edge is valid against the final state and the swap happens, or
+// the graph is left untouched. Edges present before and after are kept.
+func (g *Graph) ReplaceEdges(src string, targets []string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger.Debug("core.Graph.ReplaceEdges", slog.String("src", src), slog.Any("targets", targets))
+
+	if err := g.replaceEdges(src, targets); err != nil {
+		return err
+	}
+
+	return g.logOp(opReplaceEdges, append([]string{src}, targets...)...)
+}
+
+func (g *Graph) replaceEdges(src string, targets []string) error {
+	ksrc, ok := g.lookup[src]
+	if !ok {
+		err := VertexNotFoundErr{Key: src}
+		g.logger.Error("core.Graph.ReplaceEdges src lookup error", slog.String("key", src), slog.String("err", err.Error()))
+		return err
+	}
+
+	ktgts := make([]int, 0, len(targets))
+	seen := make(map[int]struct{}, len(targets))
+	for _, tgt := range targets {
+		ktgt, ok := g.lookup[tgt]
+		if !ok {
+			err := VertexNotFoundErr{Key: tgt}
+			g.logger.Error("core.Graph.ReplaceEdges tgt lookup error", slog.String("key", tgt), slog.String("err", err.Error()))
+			return err
+		}
+		if _, dup := seen[ktgt]; dup {
+			continue
+		}
+		seen[ktgt] = struct{}{}
+		ktgts = append(ktgts, ktgt)
+	}
+
+	// every new edge leaves src, and the cycle search stops as soon as it
+	// reaches src, so the old outgoing edges never affect the checks: they
+	// are the same against the current and the final state
+	for _, ktgt := range ktgts {
+		var err error
+		if g.exists(ktgt, ksrc) {
+			err = BidirectionalEdgeErr{Src: src, Tgt: g.keys[ktgt]}
+		} else if path := g.wouldCreateCycle(ksrc, ktgt); path != nil {
+			err = CycleErr{Src: src, Tgt: g.keys[ktgt], Path: g.keysOf(path)}
+		}
+
+		if err != nil {
+			g.logger.Error("core.Graph.ReplaceEdges invalid edge", slog.String("src", src), slog.String("tgt", g.keys[ktgt]), slog.String("err", err.Error()))
+			return err
+		}
+	}
+
+	for ktgt := range g.dependencies[ksrc] {
+		if _, keep := seen[ktgt]; !keep {
+			g.unlink(ksrc, ktgt)
+		}
+	}
+
+	for _, ktgt := range ktgts {
+		if !g.exists(ksrc, ktgt) {
+			g.link(ksrc, ktgt)
+		}
+	}
+
+	g.refreshHealth(src, ksrc)
+
+	return nil
+}
+
+func (g *Graph) RemoveVertex(key string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger.Debug("core.Graph.RemoveVertex", slog.String("key", key))
+
+	if err := g.removeVertex(key); err != nil {
+		return err
+	}
+
+	return g.logOp(opRemoveVertex, key)
+}
+
+func (g *Graph) removeVertex(key string) error {
+	v, ok := g.lookup[key]
+	if !ok {
+		err := VertexNotFoundErr{Key: key}
+		g.logger.Error("core.Graph.RemoveVertex lookup error", slog.String("key", key), slog.String("err", err.Error()))
+		return err
+	}
+
+	// drop every edge touching the vertex
+	for tgt := range g.dependencies[v] {
+		g.unlink(v, tgt)
+	}
+
+	dependents := make([]string, 0, len(g.dependents[v]))
+	for src := range g.dependents[v] {
+		dependents = append(dependents, g.keys[src])
+		g.unlink(src, v)
+	}
+
+	delete(g.lookup, key)
+	delete(g.vertexPolicy, v)
+
+	g.recordChange(ChangeEvent{Type: VertexRemoved, Key: key, Label: g.labels[v], Class: g.classLookup[g.classes[v]]})
+
+	// keep the SoA arrays dense: the last vertex takes over the freed slot
+	last := len(g.labels) - 1
+	if v != last {
+		g.logger.Debug("core.Graph.RemoveVertex relocating vertex", slog.String("key", g.keys[last]), slog.Int("from", last), slog.Int("to", v))
+		g.relocate(last, v)
+	}
+
+	delete(g.keys, last)
+	g.labels[last] = ""
+	g.labels = g.labels[:last]
+	g.classes = g.classes[:last]
+	g.status = g.status[:last]
+	g.ownStatus = g.ownStatus[:last]
+	g.lastCheck = g.lastCheck[:last]
+
+	// former dependents may recover now that the dependency is gone
+	ids := make([]int, 0, len(dependents))
+	for _, dep := range dependents {
+		ids = append(ids, g.lookup[dep])
+	}
+	g.refreshHealth(key, ids...)
 
 	return nil
 }
@@ -151,13 +366,7 @@ func (g *Graph) GetVertex(key string) (Vertex, error) {
 		return Vertex{}, err
 	}
 
-	return Vertex{
-		Key:       key,
-		Label:     g.labels[v],
-		Class:     g.classLookup[g.classes[v]],
-		Healthy:   g.healthy[v],
-		LastCheck: g.lastCheck[v],
-	}, nil
+	return g.vertex(v, g.newCauseIndex()), nil
 }
 
 func (g *Graph) Stats() Stats {
@@ -167,17 +376,27 @@ func (g *Graph) Stats() Stats {
 	g.logger.Debug("core.Graph.GraphStats")
 
 	stats := Stats{
-		TotalVertices:          len(g.keys),
-		TotalHealthyVertices:   0,
-		TotalUnhealthyVertices: 0,
-		TotalEdges:             0,
+		TotalVertices:            len(g.keys),
+		TotalHealthyVertices:     0,
+		TotalDegradedVertices:    0,
+		TotalUnhealthyVertices:   0,
+		TotalUnknownVertices:     0,
+		TotalMaintenanceVertices: 0,
+		TotalEdges:               0,
 	}
 
-	for _, healthy := range g.healthy {
-		if healthy {
+	for _, status := range g.status {
+		switch status {
+		case StatusHealthy:
 			stats.TotalHealthyVertices++
-		} else {
+		case StatusDegraded:
+			stats.TotalDegradedVertices++
+		case StatusUnhealthy:
 			stats.TotalUnhealthyVertices++
+		case StatusUnknown:
+			stats.TotalUnknownVertices++
+		case StatusMaintenance:
+			stats.TotalMaintenanceVertices++
 		}
 	}
 
@@ -185,65 +404,209 @@ func (g *Graph) Stats() Stats {
 		stats.TotalEdges += len(deps)
 	}
 
+	index := g.newCauseIndex()
 	stats.UnhealthyVertices = make([]Vertex, 0, stats.TotalUnhealthyVertices)
-	for i, healthy := range g.healthy {
-		if !healthy {
-			stats.UnhealthyVertices = append(stats.UnhealthyVertices, Vertex{
-				Key:       g.keys[i],
-				Label:     g.labels[i],
-				Class:     g.classLookup[g.classes[i]],
-				Healthy:   g.healthy[i],
-				LastCheck: g.lastCheck[i],
-			})
+	for i, status := range g.status {
+		if status == StatusUnhealthy {
+			stats.UnhealthyVertices = append(stats.UnhealthyVertices, g.vertex(i, index))
 		}
 	}
 
+	now := g.nowFn()
+	for i := range g.status {
+		if g.isStale(i, now) {
+			stats.StaleVertices = append(stats.StaleVertices, g.vertex(i, index))
+		}
+	}
+	stats.TotalStaleVertices = len(stats.StaleVertices)
+
 	g.logger.Info("core.Graph.GraphStats",
 		slog.Int("TotalVertices", stats.TotalVertices),
 		slog.Int("TotalHealthyVertices", stats.TotalHealthyVertices),
+		slog.Int("TotalDegradedVertices", stats.TotalDegradedVertices),
 		slog.Int("TotalUnhealthyVertices", stats.TotalUnhealthyVertices),
+		slog.Int("TotalUnknownVertices", stats.TotalUnknownVertices),
+		slog.Int("TotalMaintenanceVertices", stats.TotalMaintenanceVertices),
+		slog.Int("TotalStaleVertices", stats.TotalStaleVertices),
 		slog.Int("TotalEdges", stats.TotalEdges))
 
 	return stats
 }
 
+// classIndex returns the index of class in classLookup, registering the
+// class when it is not known yet.
+func (g *Graph) classIndex(class string) int {
+	for i := range g.classLookup {
+		if g.classLookup[i] == class {
+			g.logger.Debug("core.Graph.classIndex class already exists", slog.String("class", class))
+			return i
+		}
+	}
+
+	cix := len(g.classLookup)
+	g.classLookup[cix] = class
+
+	return cix
+}
+
+// vertex materializes the vertex at id. Callers building many vertices
+// under the same lock share one index.
+func (g *Graph) vertex(id int, index *causeIndex) Vertex {
+	return Vertex{
+		Key:        g.keys[id],
+		Label:      g.labels[id],
+		Class:      g.classLookup[g.classes[id]],
+		Healthy:    g.status[id] == StatusHealthy,
+		OwnHealthy: g.ownStatus[id] == StatusHealthy,
+		Status:     g.status[id],
+		OwnStatus:  g.ownStatus[id],
+		ImpactedBy: index.impactedBy(id),
+		LastCheck:  g.lastCheck[id],
+	}
+}
+
 func (g *Graph) exists(src, tgt int) bool {
 	g.logger.Debug("core.Graph.exists", slog.Int("src", src), slog.Int("tgt", tgt))
 	_, ok := g.dependencies[src][tgt]
 	return ok
 }
 
-func (g *Graph) wouldCreateCycle(src, tgt int) bool {
+// wouldCreateCycle returns the existing path from tgt to src that an edge
+// src → tgt would close into a cycle, or nil when there is none.
+func (g *Graph) wouldCreateCycle(src, tgt int) []int {
 	g.logger.Debug("core.Graph.wouldCreateCycle", slog.Int("src", src), slog.Int("tgt", tgt))
 
 	if src == tgt {
 		g.logger.Info("core.Graph.wouldCreateCycle src and tgt is the same", slog.Int("src", src), slog.Int("tgt", tgt))
-		return true
+		return []int{src}
 	}
 
-	// Check if the target vertex is reachable from the source vertex
-	visited := make(map[int]struct{}, 10)
-	stack := []int{tgt}
+	// Check if the target vertex is reachable from the source vertex,
+	// remembering from where each vertex was first reached
+	parent := make(map[int]int, 10)
+	stack := [][2]int{{tgt, -1}}
 
 	for len(stack) > 0 {
-		n := stack[len(stack)-1]
+		n, from := stack[len(stack)-1][0], stack[len(stack)-1][1]
 		stack = stack[:len(stack)-1]
 
-		if _, seen := visited[n]; seen {
+		if _, seen := parent[n]; seen {
 			continue
 		}
-		visited[n] = struct{}{}
+		parent[n] = from
 
 		if n == src {
-			return true
+			path := make([]int, 0, 8)
+			for v := src; v != -1; v = parent[v] {
+				path = append(path, v)
+			}
+			slices.Reverse(path)
+			return path
 		}
 
 		if deps, ok := g.dependencies[n]; ok {
 			for v := range deps {
-				stack = append(stack, v)
+				stack = append(stack, [2]int{v, n})
 			}
 		}
 	}
 
-	return false
+	return nil
+}
+
+func (g *Graph) keysOf(ids []int) []string {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = g.keys[id]
+	}
+	return keys
+}
+
+func (g *Graph) link(src, tgt int) {
+	if g.dependencies[src] == nil {
+		g.dependencies[src] = make(map[int]struct{}, 4)
+	}
+
+	if g.dependents[tgt] == nil {
+		g.dependents[tgt] = make(map[int]struct{}, 4)
+	}
+
+	g.dependencies[src][tgt] = struct{}{}
+	g.dependents[tgt][src] = struct{}{}
+
+	g.recordChange(ChangeEvent{Type: EdgeAdded, Src: g.keys[src], Tgt: g.keys[tgt]})
+}
+
+func (g *Graph) unlink(src, tgt int) {
+	delete(g.dependencies[src], tgt)
+	if len(g.dependencies[src]) == 0 {
+		delete(g.dependencies, src)
+	}
+
+	delete(g.dependents[tgt], src)
+	if len(g.dependents[tgt]) == 0 {
+		delete(g.dependents, tgt)
+	}
+
+	g.recordChange(ChangeEvent{Type: EdgeRemoved, Src: g.keys[src], Tgt: g.keys[tgt]})
+}
+
+// relocate moves the vertex stored at index from into index to, rewiring
+// every structure that refers to it. The slot at to must be free.
+func (g *Graph) relocate(from, to int) {
+	key := g.keys[from]
+	g.keys[to] = key
+	g.lookup[key] = to
+
+	g.labels[to] = g.labels[from]
+	g.classes[to] = g.classes[from]
+	g.status[to] = g.status[from]
+	g.ownStatus[to] = g.ownStatus[from]
+	g.lastCheck[to] = g.lastCheck[from]
+
+	if p, ok := g.vertexPolicy[from]; ok {
+		g.vertexPolicy[to] = p
+		delete(g.vertexPolicy, from)
+	}
+
+	if outs, ok := g.dependencies[from]; ok {
+		for tgt := range outs {
+			delete(g.dependents[tgt], from)
+			g.dependents[tgt][to] = struct{}{}
+		}
+		g.dependencies[to] = outs
+		delete(g.dependencies, from)
+	}
+
+	if ins, ok := g.dependents[from]; ok {
+		for src := range ins {
+			delete(g.dependencies[src], from)
+			g.dependencies[src][to] = struct{}{}
+		}
+		g.dependents[to] = ins
+		delete(g.dependents, from)
+	}
+}
+
+// healthCopy returns a graph sharing the topology and settings of g with
+// its own copy of the statuses, for simulating health changes. It must only
+// be used, and only have its statuses changed, while g is read-locked.
+func (g *Graph) healthCopy() *Graph {
+	return &Graph{
+		labels:       g.labels,
+		classes:      g.classes,
+		status:       slices.Clone(g.status),
+		ownStatus:    slices.Clone(g.ownStatus),
+		lastCheck:    g.lastCheck,
+		keys:         g.keys,
+		lookup:       g.lookup,
+		dependents:   g.dependents,
+		dependencies: g.dependencies,
+		classLookup:  g.classLookup,
+		propagation:  g.propagation,
+		classPolicy:  g.classPolicy,
+		vertexPolicy: g.vertexPolicy,
+		nowFn:        g.nowFn,
+		logger:       g.logger,
+	}
 }
@@ -0,0 +1,146 @@
+package graphlib_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func interchangeGraph() *graphlib.Graph {
+	g := buildGraph()
+	g.AddVertex("G", `<"gee">`, "database", true)
+	g.AddEdge("E", "G")
+	g.SetVertexStatus("G", graphlib.StatusDegraded)
+	g.SetVertexStatus("B", graphlib.StatusMaintenance)
+	return g
+}
+
+func equalGraphs(t *testing.T, want, got *graphlib.Graph) {
+	t.Helper()
+
+	for _, k := range []string{"A", "B", "C", "D", "E", "F", "G"} {
+		wv, _ := want.GetVertex(k)
+		gv, err := got.GetVertex(k)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(wv, gv) {
+			t.Fatalf("vertex %s: want %+v, got %+v", k, wv, gv)
+		}
+	}
+
+	if ws, gs := want.Stats(), got.Stats(); ws.TotalEdges != gs.TotalEdges || ws.TotalVertices != gs.TotalVertices {
+		t.Fatalf("stats mismatch got=%+v want=%+v", gs, ws)
+	}
+}
+
+func TestGraphMLRoundTrip(t *testing.T) {
+	g := interchangeGraph()
+
+	var buf bytes.Buffer
+	if err := g.WriteGraphML(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`<key id="last_check" for="node" attr.name="last_check" attr.type="long"></key>`,
+		`<graph id="G" edgedefault="directed">`,
+		`<data key="label">&lt;&#34;gee&#34;&gt;</data>`,
+		`<edge source="E" target="G"></edge>`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, buf.String())
+		}
+	}
+
+	loaded, err := graphlib.ReadGraphML(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	equalGraphs(t, g, loaded)
+}
+
+func TestReadGraphML_Foreign(t *testing.T) {
+	// keys named like yEd does, a missing class and no status at all
+	doc := `<?xml version="1.0"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="d0" for="node" attr.name="label" attr.type="string"/>
+  <key id="d1" for="node" attr.name="own_status" attr.type="string"/>
+  <graph edgedefault="directed">
+    <node id="app"><data key="d0">App</data></node>
+    <node id="db"><data key="d1">unhealthy</data></node>
+    <edge source="app" target="db"/>
+  </graph>
+</graphml>`
+
+	g, err := graphlib.ReadGraphML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	app, _ := g.GetVertex("app")
+	if app.Label != "App" || app.Status != graphlib.StatusUnhealthy || app.OwnStatus != graphlib.StatusHealthy {
+		t.Fatalf("unexpected vertex %+v", app)
+	}
+	if db, _ := g.GetVertex("db"); db.Label != "" || db.Class != "" || db.OwnStatus != graphlib.StatusUnhealthy {
+		t.Fatalf("unexpected vertex %+v", db)
+	}
+
+	// without a last_check the vertices count as checked at import time
+	g.SetStaleTTL(time.Hour)
+	if stale := g.StaleVertices(time.Now().UnixNano()); len(stale) != 0 {
+		t.Fatalf("expected no stale vertices, got %v", stale)
+	}
+}
+
+func TestReadGraphML_Invalid(t *testing.T) {
+	doc := `<graphml>
+  <graph edgedefault="directed">
+    <node id="A"/><node id="B"/><node id="C"/><node id="D"/><node id="E"/>
+    <edge source="A" target="B"/>
+    <edge source="B" target="C"/>
+    <edge source="C" target="A"/>
+    <edge source="D" target="E"/>
+    <edge source="E" target="D"/>
+    <edge source="D" target="D"/>
+    <edge source="A" target="X"/>
+    <edge source="A" target="D" directed="false"/>
+  </graph>
+</graphml>`
+
+	_, err := graphlib.ReadGraphML(strings.NewReader(doc))
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	if len(errs) != 5 {
+		t.Fatalf("expected 5 errors, got %d: %v", len(errs), err)
+	}
+
+	var cycle graphlib.CycleErr
+	var bidi graphlib.BidirectionalEdgeErr
+	var missing graphlib.VertexNotFoundErr
+	var imp graphlib.ImportErr
+	if !errors.As(errs[0], &cycle) || cycle.Src != "C" || cycle.Tgt != "A" {
+		t.Fatalf("expected cycle C → A, got %v", errs[0])
+	}
+	if !errors.As(errs[1], &bidi) || bidi.Src != "E" {
+		t.Fatalf("expected bidirectional E ↔ D, got %v", errs[1])
+	}
+	if !errors.As(errs[2], &cycle) || cycle.Src != "D" || cycle.Tgt != "D" {
+		t.Fatalf("expected self loop on D, got %v", errs[2])
+	}
+	if !errors.As(errs[3], &missing) || !errors.As(errs[4], &imp) {
+		t.Fatalf("expected missing vertex and undirected edge, got %v", err)
+	}
+
+	if _, err := graphlib.ReadGraphML(strings.NewReader("<graphml>")); !errors.As(err, &imp) {
+		t.Fatalf("expected ImportErr, got %v", err)
+	}
+}
@@ -3,6 +3,8 @@ package graphlib
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -65,7 +67,7 @@ func TestGraphAddVertex(t *testing.T) {
 	a1, _ := g.GetVertex("A")
 	a2, _ := g.GetVertex("A")
 
-	if a1 != a2 {
+	if !reflect.DeepEqual(a1, a2) {
 		t.Fatalf("Expected to find vertex A, but got different vertices")
 	}
 
@@ -210,14 +212,15 @@ func TestGraphStats(t *testing.T) {
 
 	stats := g.Stats()
 
+	// A and B inherit the failure of C through the new edges
 	if stats.TotalVertices != 3 {
 		t.Fatalf("Expected 3 vertices, but got %d", stats.TotalVertices)
 	}
-	if stats.TotalHealthyVertices != 2 {
-		t.Fatalf("Expected 2 healthy vertices, but got %d", stats.TotalHealthyVertices)
+	if stats.TotalHealthyVertices != 0 {
+		t.Fatalf("Expected 0 healthy vertices, but got %d", stats.TotalHealthyVertices)
 	}
-	if stats.TotalUnhealthyVertices != 1 {
-		t.Fatalf("Expected 1 unhealthy vertex, but got %d", stats.TotalUnhealthyVertices)
+	if stats.TotalUnhealthyVertices != 3 {
+		t.Fatalf("Expected 3 unhealthy vertices, but got %d", stats.TotalUnhealthyVertices)
 	}
 	if stats.TotalEdges != 2 {
 		t.Fatalf("Expected 2 edges, but got %d", stats.TotalEdges)
@@ -350,8 +353,400 @@ func TestVertexCycleErr(t *testing.T) {
 		t.Fatalf("expected CycleError, got %v", err)
 	}
 
-	want := fmt.Sprintf("edge %s → %s would create a cycle", "C", "A")
+	want := "edge C → A would create a cycle (A → B → C → A)"
 	if err.Error() != want {
 		t.Fatalf("expected cycle error message, got %v", err.Error())
 	}
+
+	if !reflect.DeepEqual(cycleErr.Path, []string{"A", "B", "C"}) {
+		t.Fatalf("expected path A → B → C, got %v", cycleErr.Path)
+	}
+}
+
+func TestGraphRemoveVertex(t *testing.T) {
+	g := NewSoAGraph(nil)
+
+	g.AddVertex("A", "A", "server", true)
+	g.AddVertex("B", "B", "server", true)
+	g.AddVertex("C", "C", "database", false)
+	g.AddVertex("D", "D", "client", true)
+
+	g.AddEdge("A", "B")
+	g.AddEdge("B", "C")
+	g.AddEdge("D", "B")
+
+	err := g.RemoveVertex("B")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	_, err = g.GetVertex("B")
+	if err == nil {
+		t.Fatal("Expected error for removed vertex B, but got none")
+	}
+
+	stats := g.Stats()
+	if stats.TotalVertices != 3 {
+		t.Fatalf("Expected 3 vertices, but got %d", stats.TotalVertices)
+	}
+	if stats.TotalEdges != 0 {
+		t.Fatalf("Expected 0 edges, but got %d", stats.TotalEdges)
+	}
+
+	// D was relocated into the slot freed by B
+	d, err := g.GetVertex("D")
+	if err != nil {
+		t.Fatalf("Expected to find vertex D, but got error %v", err)
+	}
+	if d.Label != "D" || d.Class != "client" || !d.Healthy {
+		t.Fatalf("Expected vertex D to keep its attributes, but got %v", d)
+	}
+
+	c, _ := g.GetVertex("C")
+	if c.Class != "database" || c.Healthy {
+		t.Fatalf("Expected vertex C to keep its attributes, but got %v", c)
+	}
+
+	err = g.AddEdge("D", "C")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	err = g.RemoveVertex("B")
+	var vErr VertexNotFoundErr
+	if !errors.As(err, &vErr) {
+		t.Fatalf("expected VertexNotFoundErr, got %v", err)
+	}
+}
+
+func TestGraphRemoveVertex_Relocation(t *testing.T) {
+	g := NewSoAGraph(nil)
+
+	for _, k := range []string{"A", "B", "C", "D"} {
+		g.AddVertex(k, k, "server", true)
+	}
+	g.AddEdge("A", "B")
+	g.AddEdge("D", "B")
+	g.AddEdge("C", "D")
+
+	// A sits at index 0, D is the last vertex and has edges in both directions
+	if err := g.RemoveVertex("A"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if len(g.labels) != 3 || len(g.keys) != 3 || len(g.lookup) != 3 {
+		t.Fatalf("Expected dense arrays of size 3, got labels=%d keys=%d lookup=%d", len(g.labels), len(g.keys), len(g.lookup))
+	}
+
+	for key, idx := range g.lookup {
+		if g.keys[idx] != key || g.labels[idx] != key {
+			t.Fatalf("Expected index %d to hold %s, got key=%s label=%s", idx, key, g.keys[idx], g.labels[idx])
+		}
+	}
+
+	sg, err := g.VertexNeighbors("D")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if len(sg.Edges) != 2 {
+		t.Fatalf("Expected 2 edges around D, but got %v", sg.Edges)
+	}
+
+	err = g.AddEdge("B", "C")
+	var cycleErr CycleErr
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("expected CycleErr, got %v", err)
+	}
+
+	g.AddVertex("E", "E", "server", true)
+	if e, _ := g.GetVertex("E"); e.Label != "E" {
+		t.Fatalf("Expected vertex E to be added after removal, but got %v", e)
+	}
+}
+
+func TestGraphRemoveEdge(t *testing.T) {
+	g := NewSoAGraph(nil)
+
+	g.AddVertex("A", "A", "server", true)
+	g.AddVertex("B", "B", "server", true)
+	g.AddEdge("A", "B")
+
+	err := g.RemoveEdge("A", "B")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if stats := g.Stats(); stats.TotalEdges != 0 {
+		t.Fatalf("Expected 0 edges, but got %d", stats.TotalEdges)
+	}
+
+	// the reverse edge is no longer bidirectional
+	err = g.AddEdge("B", "A")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	err = g.RemoveEdge("A", "B")
+	var eErr EdgeNotFoundErr
+	if !errors.As(err, &eErr) {
+		t.Fatalf("expected EdgeNotFoundErr, got %v", err)
+	}
+
+	want := fmt.Sprintf("edge %s → %s not found", "A", "B")
+	if err.Error() != want {
+		t.Fatalf("expected edge not found error message, got %v", err.Error())
+	}
+
+	err = g.RemoveEdge("A", "X")
+	var vErr VertexNotFoundErr
+	if !errors.As(err, &vErr) {
+		t.Fatalf("expected VertexNotFoundErr, got %v", err)
+	}
+}
+
+func TestGraphReplaceEdges(t *testing.T) {
+	g := NewSoAGraph(nil)
+
+	for _, k := range []string{"svc", "db1", "db2", "cache"} {
+		g.AddVertex(k, k, "server", true)
+	}
+	g.AddEdge("svc", "db1")
+	g.AddEdge("svc", "cache")
+
+	err := g.ReplaceEdges("svc", []string{"db2", "cache", "db2"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if g.exists(g.lookup["svc"], g.lookup["db1"]) {
+		t.Fatal("Expected svc → db1 to be removed")
+	}
+	if !g.exists(g.lookup["svc"], g.lookup["db2"]) || !g.exists(g.lookup["svc"], g.lookup["cache"]) {
+		t.Fatal("Expected svc → db2 and svc → cache to exist")
+	}
+	if len(g.dependents[g.lookup["db1"]]) != 0 {
+		t.Fatal("Expected db1 to have no dependents")
+	}
+	if stats := g.Stats(); stats.TotalEdges != 2 {
+		t.Fatalf("Expected 2 edges, but got %d", stats.TotalEdges)
+	}
+}
+
+func TestGraphReplaceEdges_Rollback(t *testing.T) {
+	g := NewSoAGraph(nil)
+
+	for _, k := range []string{"A", "B", "C", "D"} {
+		g.AddVertex(k, k, "server", true)
+	}
+	g.AddEdge("A", "B")
+	g.AddEdge("B", "C")
+	g.AddEdge("D", "A")
+
+	// C → A closes A → B → C
+	err := g.ReplaceEdges("C", []string{"D", "A"})
+	var cycleErr CycleErr
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("expected CycleErr, got %v", err)
+	}
+
+	err = g.ReplaceEdges("A", []string{"C", "D"})
+	var bErr BidirectionalEdgeErr
+	if !errors.As(err, &bErr) {
+		t.Fatalf("expected BidirectionalEdgeErr, got %v", err)
+	}
+
+	err = g.ReplaceEdges("A", []string{"C", "X"})
+	var vErr VertexNotFoundErr
+	if !errors.As(err, &vErr) {
+		t.Fatalf("expected VertexNotFoundErr, got %v", err)
+	}
+
+	if !g.exists(g.lookup["A"], g.lookup["B"]) || g.exists(g.lookup["A"], g.lookup["C"]) {
+		t.Fatal("Expected A's edges to be restored after a failed replace")
+	}
+	if stats := g.Stats(); stats.TotalEdges != 3 {
+		t.Fatalf("Expected 3 edges, but got %d", stats.TotalEdges)
+	}
+
+	// the old edge B → C no longer blocks C → B once B's edges are swapped
+	err = g.ReplaceEdges("B", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	err = g.ReplaceEdges("C", []string{"B"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+}
+
+func TestSetVertexHealth_Recovery(t *testing.T) {
+	g := NewSoAGraph(nil)
+
+	for _, k := range []string{"app", "api", "db", "cache"} {
+		g.AddVertex(k, k, "server", true)
+	}
+	g.AddEdge("app", "api")
+	g.AddEdge("api", "db")
+	g.AddEdge("api", "cache")
+
+	g.SetVertexHealth("db", false)
+	g.SetVertexHealth("cache", false)
+
+	for _, k := range []string{"app", "api", "db", "cache"} {
+		if v, _ := g.GetVertex(k); v.Healthy {
+			t.Fatalf("Expected vertex %s to be unhealthy, but got healthy", k)
+		}
+	}
+
+	// cache is still down, so api and app stay unhealthy
+	g.SetVertexHealth("db", true)
+
+	if v, _ := g.GetVertex("db"); !v.Healthy {
+		t.Fatal("Expected db to be healthy, but got unhealthy")
+	}
+	for _, k := range []string{"app", "api"} {
+		if v, _ := g.GetVertex(k); v.Healthy {
+			t.Fatalf("Expected vertex %s to be unhealthy, but got healthy", k)
+		}
+	}
+
+	g.SetVertexHealth("cache", true)
+
+	for _, k := range []string{"app", "api", "db", "cache"} {
+		if v, _ := g.GetVertex(k); !v.Healthy {
+			t.Fatalf("Expected vertex %s to be healthy, but got unhealthy", k)
+		}
+	}
+}
+
+func TestSetVertexHealth_OwnStatus(t *testing.T) {
+	g := NewSoAGraph(nil)
+
+	g.AddVertex("A", "A", "server", true)
+	g.AddVertex("B", "B", "server", true)
+	g.AddEdge("A", "B")
+
+	g.SetVertexHealth("A", false)
+	g.SetVertexHealth("B", false)
+	g.SetVertexHealth("B", true)
+
+	// A reported itself as unhealthy, B recovering does not change that
+	if v, _ := g.GetVertex("A"); v.Healthy {
+		t.Fatal("Expected A to stay unhealthy, but got healthy")
+	}
+
+	g.SetVertexHealth("A", true)
+	if v, _ := g.GetVertex("A"); !v.Healthy {
+		t.Fatal("Expected A to be healthy, but got unhealthy")
+	}
+}
+
+func TestGraphRemoveEdge_Recovery(t *testing.T) {
+	g := NewSoAGraph(nil)
+
+	g.AddVertex("svc", "svc", "server", true)
+	g.AddVertex("db1", "db1", "database", true)
+	g.AddVertex("db2", "db2", "database", true)
+	g.AddEdge("svc", "db1")
+
+	g.SetVertexHealth("db1", false)
+	if v, _ := g.GetVertex("svc"); v.Healthy {
+		t.Fatal("Expected svc to be unhealthy, but got healthy")
+	}
+
+	g.ReplaceEdges("svc", []string{"db2"})
+	if v, _ := g.GetVertex("svc"); !v.Healthy {
+		t.Fatal("Expected svc to recover after moving to db2, but got unhealthy")
+	}
+
+	g.AddEdge("svc", "db1")
+	g.SetVertexHealth("db1", false)
+	g.RemoveVertex("db1")
+	if v, _ := g.GetVertex("svc"); !v.Healthy {
+		t.Fatal("Expected svc to recover after db1 was removed, but got unhealthy")
+	}
+}
+
+func TestVertexOwnAndImpactedHealth(t *testing.T) {
+	g := NewSoAGraph(nil)
+
+	for _, k := range []string{"app", "api", "db", "cache", "disk"} {
+		g.AddVertex(k, k, "server", true)
+	}
+	g.AddEdge("app", "api")
+	g.AddEdge("api", "db")
+	g.AddEdge("api", "cache")
+	g.AddEdge("db", "disk")
+
+	g.SetVertexHealth("disk", false)
+	g.SetVertexHealth("cache", false)
+
+	app, _ := g.GetVertex("app")
+	if app.Healthy || !app.OwnHealthy {
+		t.Fatalf("Expected app to be impacted but reporting healthy, got %+v", app)
+	}
+	if !reflect.DeepEqual(app.ImpactedBy, []string{"cache", "disk"}) {
+		t.Fatalf("Expected app to be impacted by cache and disk, got %v", app.ImpactedBy)
+	}
+
+	disk, _ := g.GetVertex("disk")
+	if disk.Healthy || disk.OwnHealthy || len(disk.ImpactedBy) != 0 {
+		t.Fatalf("Expected disk to be a root cause, got %+v", disk)
+	}
+
+	// api reports itself as down as well, but it is not a root cause
+	g.SetVertexHealth("api", false)
+	app, _ = g.GetVertex("app")
+	if !reflect.DeepEqual(app.ImpactedBy, []string{"cache", "disk"}) {
+		t.Fatalf("Expected app to still be impacted by cache and disk, got %v", app.ImpactedBy)
+	}
+
+	g.SetVertexHealth("disk", true)
+	db, _ := g.GetVertex("db")
+	if !db.Healthy || db.ImpactedBy != nil {
+		t.Fatalf("Expected db to recover, got %+v", db)
+	}
+}
+
+func TestWALWriteError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.wal")
+
+	g, err := OpenGraph(path, WALOptions{Sync: WALSyncInterval}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g.wal.opts.SyncInterval != defaultSyncInterval {
+		t.Fatalf("expected the default sync interval, got %v", g.wal.opts.SyncInterval)
+	}
+
+	g.AddVertex("A", "A", "server", true)
+	g.AddVertex("B", "B", "server", true)
+
+	// the log can no longer be written
+	g.wal.f.Close()
+
+	var wErr WALErr
+	if err := g.AddEdge("A", "B"); !errors.As(err, &wErr) {
+		t.Fatalf("expected WALErr, got %v", err)
+	}
+	if err := g.SetVertexHealth("B", false); !errors.As(err, &wErr) {
+		t.Fatalf("expected WALErr, got %v", err)
+	}
+	err = g.Batch(func(tx *Tx) error {
+		tx.AddVertex("C", "C", "server", true)
+		tx.AddEdge("C", "A")
+		return nil
+	})
+	if !errors.As(err, &wErr) {
+		t.Fatalf("expected WALErr, got %v", err)
+	}
+
+	// the changes are still applied in memory
+	if v, _ := g.GetVertex("C"); v.Healthy {
+		t.Fatalf("expected C to be impacted by B, got %+v", v)
+	}
+
+	if err := g.CloseWAL(); err == nil {
+		t.Fatal("expected CloseWAL to report the write error")
+	}
 }
@@ -2,6 +2,8 @@ package graphlib
 
 import (
 	"log/slog"
+	"slices"
+	"sort"
 )
 
 func (g *Graph) ClearHealthyStatus() {
@@ -9,36 +11,379 @@ func (g *Graph) ClearHealthyStatus() {
 	defer g.mu.Unlock()
 
 	g.logger.Debug("core.Graph.ClearHealthyStatus")
-	for k := range g.healthy {
-		g.healthy[k] = true
+
+	g.clearHealth()
+	g.logOp(opClearHealth)
+}
+
+func (g *Graph) clearHealth() {
+	for k := range g.status {
+		old := g.status[k]
+		g.status[k] = StatusHealthy
+		g.ownStatus[k] = StatusHealthy
+
+		if old != StatusHealthy {
+			g.emitHealth(k, old, StatusHealthy, "")
+		}
+	}
+}
+
+// SetPropagationRule configures the status a dependent inherits from a
+// dependency in the given status. By default Unhealthy and Degraded
+// dependencies pass their status on, and every other status has no impact.
+func (g *Graph) SetPropagationRule(dependency, dependent HealthStatus) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger.Debug("core.Graph.SetPropagationRule", slog.String("dependency", dependency.String()), slog.String("dependent", dependent.String()))
+
+	for _, s := range []HealthStatus{dependency, dependent} {
+		if !s.valid() {
+			err := InvalidHealthStatusErr{Status: s.String()}
+			g.logger.Error("core.Graph.SetPropagationRule invalid status", slog.String("err", err.Error()))
+			return err
+		}
+	}
+
+	g.propagation[dependency] = dependent
+	g.refreshAllHealth()
+
+	return nil
+}
+
+// RootCauses returns the deepest failing vertices behind key: vertices
+// reachable through dependencies that pass a failure on, which have no such
+// dependency of their own. A failing vertex with healthy dependencies is its
+// own root cause.
+func (g *Graph) RootCauses(key string) ([]Vertex, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.RootCauses", slog.String("key", key))
+
+	v, ok := g.lookup[key]
+	if !ok {
+		err := VertexNotFoundErr{Key: key}
+		g.logger.Error("core.Graph.RootCauses lookup error", slog.String("key", key), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	index := g.newCauseIndex()
+	ids := index.rootCausesBelow(v)
+	if index.isRootCause(v) {
+		ids = []int{v}
+	} else if !g.failing(v) {
+		ids = nil
 	}
+
+	causes := make([]Vertex, 0, len(ids))
+	for _, d := range ids {
+		causes = append(causes, g.vertex(d, index))
+	}
+
+	sort.Slice(causes, func(i, j int) bool { return causes[i].Key < causes[j].Key })
+
+	return causes, nil
 }
 
+// AllRootCauses groups every failing vertex under the root causes behind
+// it. A vertex impacted by several root causes appears in each group.
+func (g *Graph) AllRootCauses() []RootCause {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.AllRootCauses")
+
+	var out []RootCause
+	index := g.newCauseIndex()
+
+	for v := range g.status {
+		if !index.isRootCause(v) {
+			continue
+		}
+
+		rc := RootCause{Cause: g.vertex(v, index), Impacted: make([]Vertex, 0, len(g.dependents[v]))}
+
+		seen := map[int]struct{}{v: {}}
+		stack := []int{v}
+		for len(stack) > 0 {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			if !g.contributes(n) {
+				continue
+			}
+
+			for d := range g.dependents[n] {
+				if _, dup := seen[d]; dup || index.inheritedBy(d) == StatusHealthy {
+					continue
+				}
+				seen[d] = struct{}{}
+				stack = append(stack, d)
+				rc.Impacted = append(rc.Impacted, g.vertex(d, index))
+			}
+		}
+
+		sort.Slice(rc.Impacted, func(i, j int) bool { return rc.Impacted[i].Key < rc.Impacted[j].Key })
+		out = append(out, rc)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Cause.Key < out[j].Cause.Key })
+
+	g.logger.Info("core.Graph.AllRootCauses", slog.Int("RootCauses", len(out)))
+
+	return out
+}
+
+// SetVertexHealth records the health reported by the vertex itself and
+// re-evaluates every transitive dependent. It is a shorthand for
+// SetVertexStatus with StatusHealthy or StatusUnhealthy.
 func (g *Graph) SetVertexHealth(key string, health bool) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	g.logger.Debug("core.Graph.SetVertexHealth", slog.String("key", key), slog.Bool("health", health))
 
+	if err := g.setVertexStatus(key, statusOf(health)); err != nil {
+		return err
+	}
+
+	return g.logOp(opSetStatus, key, statusOf(health).String())
+}
+
+// SetVertexStatus records the status reported by the vertex itself and
+// re-evaluates every transitive dependent. The effective status of a vertex
+// is the worst of its own status and what its dependencies impose on it
+// through the propagation rules.
+func (g *Graph) SetVertexStatus(key string, status HealthStatus) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger.Debug("core.Graph.SetVertexStatus", slog.String("key", key), slog.String("status", status.String()))
+
+	if err := g.setVertexStatus(key, status); err != nil {
+		return err
+	}
+
+	return g.logOp(opSetStatus, key, status.String())
+}
+
+func (g *Graph) setVertexStatus(key string, status HealthStatus) error {
+	if !status.valid() {
+		err := InvalidHealthStatusErr{Status: status.String()}
+		g.logger.Error("core.Graph.setVertexStatus invalid status", slog.String("key", key), slog.String("err", err.Error()))
+		return err
+	}
+
 	v, ok := g.lookup[key]
 	if !ok {
 		err := VertexNotFoundErr{Key: key}
-		g.logger.Error("core.Graph.SetVertexHealth lookup error", slog.String("key", key), slog.String("err", err.Error()))
+		g.logger.Error("core.Graph.setVertexStatus lookup error", slog.String("key", key), slog.String("err", err.Error()))
 		return err
 	}
 
-	g.logger.Info("core.Graph.SetVertexHealth lookup success. The health status will be changed", slog.String("key", key), slog.Int("id", v), slog.Bool("health", health))
+	g.logger.Info("core.Graph.setVertexStatus lookup success. The health status will be changed", slog.String("key", key), slog.Int("id", v), slog.String("status", status.String()))
 
-	g.propagateUnhealthy(v)
+	g.ownStatus[v] = status
+	g.lastCheck[v] = g.nowFn()
+
+	g.refreshHealth(key, v)
 
 	return nil
 }
 
-func (g *Graph) propagateUnhealthy(v int) {
-	g.healthy[v] = false
-	g.lastCheck[v] = g.nowFn()
+// refreshHealth re-evaluates the given vertices, and the dependents of
+// every vertex whose effective status changes, until nothing changes any
+// more. Only the part of the graph that actually changes is visited, so a
+// report that changes nothing costs a single evaluation. A vertex may be
+// evaluated again when one of its dependencies changes after it, but each
+// vertex whose final status differs from where it started emits a single
+// event, in the order the changes were first seen. cause is reported on
+// those events.
+func (g *Graph) refreshHealth(cause string, vs ...int) {
+	queued := make(map[int]struct{}, len(vs))
+	queue := make([]int, 0, len(vs))
+	for _, v := range vs {
+		if _, dup := queued[v]; !dup {
+			queued[v] = struct{}{}
+			queue = append(queue, v)
+		}
+	}
+
+	// the status each changed vertex had before the refresh
+	before := make(map[int]HealthStatus)
+	var changed []int
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		delete(queued, n)
+
+		status := g.evaluateHealth(n)
+		old := g.status[n]
+		if status == old {
+			continue
+		}
+
+		if _, seen := before[n]; !seen {
+			before[n] = old
+			changed = append(changed, n)
+		}
+		g.status[n] = status
+
+		for d := range g.dependents[n] {
+			if _, dup := queued[d]; !dup {
+				queued[d] = struct{}{}
+				queue = append(queue, d)
+			}
+		}
+	}
 
-	for d := range g.dependents[v] {
-		g.propagateUnhealthy(d)
+	for _, n := range changed {
+		if old, status := before[n], g.status[n]; status != old {
+			g.logger.Debug("core.Graph.refreshHealth status changed", slog.String("key", g.keys[n]), slog.String("old", old.String()), slog.String("new", status.String()))
+			g.emitHealth(n, old, status, cause)
+		}
 	}
 }
+
+// refreshAllHealth re-evaluates every vertex, dependencies first so each
+// one is evaluated once.
+func (g *Graph) refreshAllHealth() {
+	// the graph is a DAG, ordering it cannot fail
+	order, _ := g.topologicalOrder(g.allIDs())
+	g.refreshHealth("", order...)
+}
+
+func (g *Graph) evaluateHealth(v int) HealthStatus {
+	return worse(g.ownStatus[v], g.inherited(v))
+}
+
+// inherited returns the status the dependencies of v impose on it, as
+// decided by the propagation policy of v.
+func (g *Graph) inherited(v int) HealthStatus {
+	failing := 0
+	status := StatusHealthy
+
+	for d := range g.dependencies[v] {
+		if imposed := g.propagation[g.status[d]]; imposed != StatusHealthy {
+			failing++
+			status = worse(status, imposed)
+		}
+	}
+
+	return g.policyFor(v).apply(len(g.dependencies[v]), failing, status)
+}
+
+// contributes reports whether v passes a failure on to its dependents.
+func (g *Graph) contributes(v int) bool {
+	return g.propagation[g.status[v]] != StatusHealthy
+}
+
+func (g *Graph) failing(v int) bool {
+	return g.status[v].impaired() || g.contributes(v)
+}
+
+// causeIndex memoizes, for the reads made under one lock, what the
+// dependencies impose on each vertex and the root causes found below it, so
+// materializing many vertices walks every failing chain once.
+type causeIndex struct {
+	g         *Graph
+	inherited map[int]HealthStatus
+	causes    map[int][]int
+}
+
+func (g *Graph) newCauseIndex() *causeIndex {
+	return &causeIndex{
+		g:         g,
+		inherited: make(map[int]HealthStatus),
+		causes:    make(map[int][]int),
+	}
+}
+
+func (c *causeIndex) inheritedBy(v int) HealthStatus {
+	s, ok := c.inherited[v]
+	if !ok {
+		s = c.g.inherited(v)
+		c.inherited[v] = s
+	}
+	return s
+}
+
+// impactedBy returns the keys of the root causes that reach v through a
+// chain of failing dependencies, the vertices RootCauses reports for v when
+// v is not a root cause itself.
+func (c *causeIndex) impactedBy(v int) []string {
+	if !c.g.failing(v) {
+		return nil
+	}
+
+	ids := c.rootCausesBelow(v)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	causes := c.g.keysOf(ids)
+	sort.Strings(causes)
+
+	return causes
+}
+
+// rootCausesBelow returns the sorted ids of the root causes reached from v
+// through dependencies that pass a failure on and whose failure is actually
+// inherited by the vertex above. The sets of the vertices below are
+// computed first and reused.
+func (c *causeIndex) rootCausesBelow(v int) []int {
+	if ids, ok := c.causes[v]; ok {
+		return ids
+	}
+
+	stack := []int{v}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+
+		if _, done := c.causes[n]; done {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if c.inheritedBy(n) == StatusHealthy {
+			c.causes[n] = nil
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		ready := true
+		for d := range c.g.dependencies[n] {
+			if _, done := c.causes[d]; !done && c.g.contributes(d) {
+				stack = append(stack, d)
+				ready = false
+			}
+		}
+		if !ready {
+			continue
+		}
+		stack = stack[:len(stack)-1]
+
+		var ids []int
+		for d := range c.g.dependencies[n] {
+			if !c.g.contributes(d) {
+				continue
+			}
+			// a dependency passing a failure on is a root cause exactly
+			// when it inherits nothing, and then has nothing below it
+			if c.inheritedBy(d) == StatusHealthy {
+				ids = append(ids, d)
+			}
+			ids = append(ids, c.causes[d]...)
+		}
+		slices.Sort(ids)
+		c.causes[n] = slices.Compact(ids)
+	}
+
+	return c.causes[v]
+}
+
+func (c *causeIndex) isRootCause(v int) bool {
+	return c.g.failing(v) && c.inheritedBy(v) == StatusHealthy
+}
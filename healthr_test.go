@@ -0,0 +1,330 @@
+package graphlib_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func keys(vs []graphlib.Vertex) []string {
+	out := make([]string, 0, len(vs))
+	for _, v := range vs {
+		out = append(out, v.Key)
+	}
+	return out
+}
+
+func equalKeys(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRootCauses(t *testing.T) {
+	g := buildGraph()
+
+	g.SetVertexHealth("E", false)
+	g.SetVertexHealth("B", false)
+
+	rc, err := g.RootCauses("A")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := keys(rc); !equalKeys(got, []string{"B", "E"}) {
+		t.Fatalf("root causes mismatch got=%v want=[B E]", got)
+	}
+
+	rc, _ = g.RootCauses("E")
+	if got := keys(rc); !equalKeys(got, []string{"E"}) {
+		t.Fatalf("root causes mismatch got=%v want=[E]", got)
+	}
+
+	// D reports itself down on top of E, but E is still the deepest failure
+	g.SetVertexHealth("D", false)
+	rc, _ = g.RootCauses("F")
+	if got := keys(rc); !equalKeys(got, []string{"E"}) {
+		t.Fatalf("root causes mismatch got=%v want=[E]", got)
+	}
+
+	g.SetVertexHealth("E", true)
+	rc, _ = g.RootCauses("F")
+	if got := keys(rc); !equalKeys(got, []string{"D"}) {
+		t.Fatalf("root causes mismatch got=%v want=[D]", got)
+	}
+
+	rc, _ = g.RootCauses("E")
+	if len(rc) != 0 {
+		t.Fatalf("expected no root causes for a healthy vertex, got %v", keys(rc))
+	}
+}
+
+func TestImpactedByMatchesRootCauses(t *testing.T) {
+	g := graphlib.NewSoAGraph(nil)
+	for _, k := range []string{"A", "B", "C"} {
+		g.AddVertex(k, k, "server", true)
+	}
+	g.AddEdge("A", "B")
+	g.AddEdge("B", "C")
+
+	g.SetVertexHealth("B", false)
+	g.SetVertexHealth("C", false)
+
+	for _, k := range []string{"A", "B"} {
+		v, _ := g.GetVertex(k)
+		rc, _ := g.RootCauses(k)
+		if !equalKeys(v.ImpactedBy, []string{"C"}) || !equalKeys(keys(rc), []string{"C"}) {
+			t.Fatalf("%s: want ImpactedBy and RootCauses [C], got %v and %v", k, v.ImpactedBy, keys(rc))
+		}
+	}
+
+	if s := g.Stats(); len(s.UnhealthyVertices) != 3 || !equalKeys(s.UnhealthyVertices[0].ImpactedBy, []string{"C"}) {
+		t.Fatalf("unexpected unhealthy vertices %+v", s.UnhealthyVertices)
+	}
+}
+
+func TestRootCauses_NotFound(t *testing.T) {
+	g := buildGraph()
+
+	_, err := g.RootCauses("X")
+	var nf graphlib.VertexNotFoundErr
+	if !errors.As(err, &nf) || nf.Key != "X" {
+		t.Fatalf("expected VertexNotFoundErr(X), got %v", err)
+	}
+}
+
+func TestAllRootCauses(t *testing.T) {
+	g := buildGraph()
+
+	if got := g.AllRootCauses(); len(got) != 0 {
+		t.Fatalf("expected no root causes, got %v", got)
+	}
+
+	g.SetVertexHealth("E", false)
+	g.SetVertexHealth("B", false)
+
+	got := g.AllRootCauses()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 root causes, got %d", len(got))
+	}
+
+	if got[0].Cause.Key != "B" || !equalKeys(keys(got[0].Impacted), []string{"A"}) {
+		t.Fatalf("unexpected group for B: %v -> %v", got[0].Cause.Key, keys(got[0].Impacted))
+	}
+	if got[1].Cause.Key != "E" || !equalKeys(keys(got[1].Impacted), []string{"A", "C", "D", "F"}) {
+		t.Fatalf("unexpected group for E: %v -> %v", got[1].Cause.Key, keys(got[1].Impacted))
+	}
+}
+
+func TestSetVertexStatus(t *testing.T) {
+	g := buildGraph()
+
+	g.SetVertexStatus("E", graphlib.StatusDegraded)
+	g.SetVertexStatus("B", graphlib.StatusMaintenance)
+
+	for k, want := range map[string]graphlib.HealthStatus{
+		"A": graphlib.StatusDegraded,
+		"B": graphlib.StatusMaintenance,
+		"C": graphlib.StatusDegraded,
+		"D": graphlib.StatusDegraded,
+		"E": graphlib.StatusDegraded,
+		"F": graphlib.StatusDegraded,
+	} {
+		v, _ := g.GetVertex(k)
+		if v.Status != want {
+			t.Fatalf("vertex %s: want status %v, got %v", k, want, v.Status)
+		}
+		if v.Healthy {
+			t.Fatalf("vertex %s: expected Healthy to be false", k)
+		}
+	}
+
+	// the worst status wins
+	g.SetVertexStatus("C", graphlib.StatusUnhealthy)
+	if v, _ := g.GetVertex("A"); v.Status != graphlib.StatusUnhealthy {
+		t.Fatalf("want A unhealthy, got %v", v.Status)
+	}
+	if v, _ := g.GetVertex("F"); v.Status != graphlib.StatusDegraded {
+		t.Fatalf("want F degraded, got %v", v.Status)
+	}
+
+	stats := g.Stats()
+	if stats.TotalUnhealthyVertices != 2 || stats.TotalDegradedVertices != 3 || stats.TotalMaintenanceVertices != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if got := keys(stats.UnhealthyVertices); len(got) != 2 {
+		t.Fatalf("expected 2 unhealthy vertices, got %v", got)
+	}
+
+	err := g.SetVertexStatus("A", graphlib.HealthStatus(42))
+	var sErr graphlib.InvalidHealthStatusErr
+	if !errors.As(err, &sErr) {
+		t.Fatalf("expected InvalidHealthStatusErr, got %v", err)
+	}
+}
+
+func TestAddEdgePropagatesHealth(t *testing.T) {
+	g := graphlib.NewSoAGraph(nil)
+	g.AddVertex("A", "A", "app", true)
+	g.AddVertex("B", "B", "db", false)
+
+	if err := g.AddEdge("A", "B"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	v, _ := g.GetVertex("A")
+	if v.Status != graphlib.StatusUnhealthy || !equalKeys(v.ImpactedBy, []string{"B"}) {
+		t.Fatalf("want A unhealthy impacted by B, got %v %v", v.Status, v.ImpactedBy)
+	}
+	if rc, _ := g.RootCauses("A"); !equalKeys(keys(rc), []string{"B"}) {
+		t.Fatalf("root causes mismatch got=%v want=[B]", keys(rc))
+	}
+}
+
+func TestSetPropagationRule(t *testing.T) {
+	g := buildGraph()
+
+	g.SetVertexHealth("E", false)
+
+	err := g.SetPropagationRule(graphlib.StatusUnhealthy, graphlib.StatusDegraded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v, _ := g.GetVertex("E"); v.Status != graphlib.StatusUnhealthy {
+		t.Fatalf("want E unhealthy, got %v", v.Status)
+	}
+	for _, k := range []string{"A", "C", "D", "F"} {
+		if v, _ := g.GetVertex(k); v.Status != graphlib.StatusDegraded {
+			t.Fatalf("want %s degraded, got %v", k, v.Status)
+		}
+	}
+
+	rc, _ := g.RootCauses("A")
+	if got := keys(rc); !equalKeys(got, []string{"E"}) {
+		t.Fatalf("root causes mismatch got=%v want=[E]", got)
+	}
+
+	// vertices that were never checked now take their dependents down too
+	g.AddVertex("G", "G", "server", true)
+	g.AddEdge("B", "G")
+	g.SetPropagationRule(graphlib.StatusUnknown, graphlib.StatusUnhealthy)
+	g.SetVertexStatus("G", graphlib.StatusUnknown)
+
+	if v, _ := g.GetVertex("B"); v.Status != graphlib.StatusUnhealthy {
+		t.Fatalf("want B unhealthy, got %v", v.Status)
+	}
+	rc, _ = g.RootCauses("B")
+	if got := keys(rc); !equalKeys(got, []string{"G"}) {
+		t.Fatalf("root causes mismatch got=%v want=[G]", got)
+	}
+
+	err = g.SetPropagationRule(graphlib.HealthStatus(42), graphlib.StatusHealthy)
+	var sErr graphlib.InvalidHealthStatusErr
+	if !errors.As(err, &sErr) {
+		t.Fatalf("expected InvalidHealthStatusErr, got %v", err)
+	}
+}
+
+func TestHealthStatusText(t *testing.T) {
+	for _, s := range []graphlib.HealthStatus{
+		graphlib.StatusUnknown,
+		graphlib.StatusHealthy,
+		graphlib.StatusDegraded,
+		graphlib.StatusUnhealthy,
+		graphlib.StatusMaintenance,
+	} {
+		text, err := s.MarshalText()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var got graphlib.HealthStatus
+		if err := got.UnmarshalText(text); err != nil || got != s {
+			t.Fatalf("round trip of %v failed: got %v, err %v", s, got, err)
+		}
+	}
+
+	if s, err := graphlib.ParseHealthStatus("Degraded"); err != nil || s != graphlib.StatusDegraded {
+		t.Fatalf("expected degraded, got %v, err %v", s, err)
+	}
+
+	_, err := graphlib.ParseHealthStatus("broken")
+	var sErr graphlib.InvalidHealthStatusErr
+	if !errors.As(err, &sErr) || sErr.Status != "broken" {
+		t.Fatalf("expected InvalidHealthStatusErr, got %v", err)
+	}
+}
+
+func BenchmarkStatsFailingChain(b *testing.B) {
+	const n = 8000
+
+	g := graphlib.NewSoAGraph(nil)
+	err := g.Batch(func(tx *graphlib.Tx) error {
+		for i := 0; i < n; i++ {
+			tx.AddVertex(fmt.Sprintf("v%04d", i), "", "server", true)
+		}
+		for i := 0; i+1 < n; i++ {
+			tx.AddEdge(fmt.Sprintf("v%04d", i), fmt.Sprintf("v%04d", i+1))
+		}
+		return nil
+	})
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+	g.SetVertexHealth(fmt.Sprintf("v%04d", n-1), false)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if stats := g.Stats(); stats.TotalUnhealthyVertices != n {
+			b.Fatalf("want %d unhealthy vertices, got %d", n, stats.TotalUnhealthyVertices)
+		}
+	}
+}
+
+func BenchmarkAddEdge(b *testing.B) {
+	const n = 4000
+
+	for i := 0; i < b.N; i++ {
+		g := graphlib.NewSoAGraph(nil)
+		for j := 0; j < n; j++ {
+			g.AddVertex(fmt.Sprintf("v%04d", j), "", "server", j%100 != 0)
+		}
+		for j := 0; j < n; j++ {
+			for k := 1; k <= 20 && j+k < n; k += 1 + j%3 {
+				g.AddEdge(fmt.Sprintf("v%04d", j), fmt.Sprintf("v%04d", j+k))
+			}
+		}
+	}
+}
+
+func BenchmarkSetVertexHealthNoop(b *testing.B) {
+	const n = 4000
+
+	g := graphlib.NewSoAGraph(nil)
+	err := g.Batch(func(tx *graphlib.Tx) error {
+		for i := 0; i < n; i++ {
+			tx.AddVertex(fmt.Sprintf("v%04d", i), "", "server", true)
+		}
+		for i := 0; i+1 < n; i++ {
+			tx.AddEdge(fmt.Sprintf("v%04d", i), fmt.Sprintf("v%04d", i+1))
+		}
+		return nil
+	})
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+
+	leaf := fmt.Sprintf("v%04d", n-1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.SetVertexHealth(leaf, true)
+	}
+}
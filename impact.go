@@ -0,0 +1,157 @@
+package graphlib
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// Impact simulates the vertices of keys reporting themselves unhealthy and
+// reports which vertices would change status as a result, with the same
+// propagation rules and policies as SetVertexStatus. It works on a copy of
+// the health state: nothing changes and no event is emitted. Unknown keys
+// are listed in NotFound.
+func (g *Graph) Impact(keys ...string) ImpactReport {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.Impact", slog.Any("keys", keys))
+
+	sim := g.healthCopy()
+
+	report := ImpactReport{
+		Affected: make(map[string][]Vertex),
+		Counts:   make(map[string]int),
+	}
+
+	before := slices.Clone(sim.status)
+	seeds := make(map[int]struct{}, len(keys))
+	for _, key := range keys {
+		v, ok := sim.lookup[key]
+		if !ok {
+			report.NotFound = append(report.NotFound, key)
+			continue
+		}
+		if _, dup := seeds[v]; dup {
+			continue
+		}
+		seeds[v] = struct{}{}
+		sim.ownStatus[v] = StatusUnhealthy
+	}
+
+	ids := make([]int, 0, len(seeds))
+	for v := range seeds {
+		ids = append(ids, v)
+	}
+	sim.refreshHealth("", ids...)
+
+	affected := make(map[int]struct{})
+	for v := range sim.status {
+		if _, seed := seeds[v]; !seed && sim.status[v] != before[v] {
+			affected[v] = struct{}{}
+		}
+	}
+
+	byKey := func(a, b Vertex) int { return strings.Compare(a.Key, b.Key) }
+
+	index := sim.newCauseIndex()
+	for v := range seeds {
+		report.Sources = append(report.Sources, sim.vertex(v, index))
+	}
+	slices.SortFunc(report.Sources, byKey)
+
+	for v := range affected {
+		vx := sim.vertex(v, index)
+		report.Affected[vx.Class] = append(report.Affected[vx.Class], vx)
+	}
+	for class, vs := range report.Affected {
+		slices.SortFunc(vs, byKey)
+		report.Counts[class] = len(vs)
+		report.Total += len(vs)
+	}
+
+	report.Chain = sim.longestChain(seeds, affected)
+
+	return report
+}
+
+// longestChain returns the longest path going up from a seed through
+// affected dependents. Ties go to the chain ending at the smallest key, then
+// to the one coming through the smallest dependency.
+func (g *Graph) longestChain(seeds, affected map[int]struct{}) []string {
+	member := func(v int) bool {
+		if _, ok := seeds[v]; ok {
+			return true
+		}
+		_, ok := affected[v]
+		return ok
+	}
+
+	// walk the members in topological order, counting only the edges
+	// between members
+	pending := make(map[int]int, len(seeds)+len(affected))
+	queue := make([]int, 0, len(seeds))
+	for _, set := range []map[int]struct{}{seeds, affected} {
+		for v := range set {
+			for d := range g.dependencies[v] {
+				if member(d) {
+					pending[v]++
+				}
+			}
+			if pending[v] == 0 {
+				queue = append(queue, v)
+			}
+		}
+	}
+
+	length := make(map[int]int, len(pending))
+	prev := make(map[int]int, len(pending))
+	end := -1
+
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+
+		length[v] = 1
+		prev[v] = -1
+		if _, seed := seeds[v]; !seed {
+			// an affected vertex always has a failing member below it;
+			// members with no member dependency start a chain of their own
+			length[v] = 0
+		}
+		for d := range g.dependencies[v] {
+			if !member(d) || length[d] == 0 {
+				continue
+			}
+			if l := length[d] + 1; l > length[v] || l == length[v] && g.keys[d] < g.keys[prev[v]] {
+				length[v], prev[v] = l, d
+			}
+		}
+
+		if length[v] > 0 && (end == -1 || length[v] > length[end] || length[v] == length[end] && g.keys[v] < g.keys[end]) {
+			end = v
+		}
+
+		for d := range g.dependents[v] {
+			if !member(d) {
+				continue
+			}
+			pending[d]--
+			if pending[d] == 0 {
+				queue = append(queue, d)
+			}
+		}
+	}
+
+	if end == -1 {
+		return nil
+	}
+
+	var chain []string
+	for v := end; v != -1; v = prev[v] {
+		chain = append(chain, g.keys[v])
+	}
+	slices.Reverse(chain)
+
+	return chain
+}
@@ -0,0 +1,81 @@
+package graphlib_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func TestImpact(t *testing.T) {
+	g := buildGraph()
+	g.AddVertex("G", "", "database", true)
+	g.AddEdge("E", "G")
+	seq := g.LastSeq()
+
+	events, cancel := g.Subscribe(graphlib.HealthFilter{})
+	defer cancel()
+
+	report := g.Impact("G", "X")
+
+	if got := keys(report.Sources); !reflect.DeepEqual(got, []string{"G"}) || report.Sources[0].Status != graphlib.StatusUnhealthy {
+		t.Fatalf("unexpected sources %+v", report.Sources)
+	}
+	if !reflect.DeepEqual(report.NotFound, []string{"X"}) {
+		t.Fatalf("unexpected not found %v", report.NotFound)
+	}
+
+	if got := keys(report.Affected["server"]); !reflect.DeepEqual(got, []string{"A", "C", "D", "E", "F"}) {
+		t.Fatalf("unexpected affected %v", got)
+	}
+	if report.Counts["server"] != 5 || report.Total != 5 || len(report.Affected) != 1 {
+		t.Fatalf("unexpected counts %v, total %d", report.Counts, report.Total)
+	}
+	if a := report.Affected["server"][0]; a.Status != graphlib.StatusUnhealthy || !reflect.DeepEqual(a.ImpactedBy, []string{"G"}) {
+		t.Fatalf("expected simulated status on %+v", a)
+	}
+
+	if want := []string{"G", "E", "D", "C", "A"}; !reflect.DeepEqual(report.Chain, want) {
+		t.Fatalf("want chain %v, got %v", want, report.Chain)
+	}
+
+	// nothing changed
+	if v, _ := g.GetVertex("A"); !v.Healthy {
+		t.Fatalf("expected A to stay healthy, got %+v", v)
+	}
+	if g.LastSeq() != seq || g.Stats().TotalUnhealthyVertices != 0 {
+		t.Fatalf("impact analysis changed the graph")
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event %+v", ev)
+	default:
+	}
+}
+
+func TestImpact_Policies(t *testing.T) {
+	g := buildPool()
+	if err := g.SetClassPolicy("pool", graphlib.PropagationPolicy{Mode: graphlib.PolicyAll}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// one replica down does not take the pool down
+	report := g.Impact("r1")
+	if report.Total != 0 || !reflect.DeepEqual(report.Chain, []string{"r1"}) {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	report = g.Impact("r1", "r2", "r3", "r4", "r5")
+	if report.Counts["pool"] != 1 || report.Counts["service"] != 1 || report.Total != 2 {
+		t.Fatalf("unexpected counts %v", report.Counts)
+	}
+	if want := []string{"r1", "lb", "app"}; !reflect.DeepEqual(report.Chain, want) {
+		t.Fatalf("want chain %v, got %v", want, report.Chain)
+	}
+
+	// already unhealthy vertices are not affected again
+	g.SetVertexStatus("lb", graphlib.StatusUnhealthy)
+	if report := g.Impact("r1", "r2", "r3", "r4", "r5"); report.Total != 0 {
+		t.Fatalf("expected nothing new to be affected, got %+v", report.Affected)
+	}
+}
@@ -0,0 +1,95 @@
+package graphlib
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+// importer builds a graph from a document in another format. It keeps
+// going after an invalid element so every one of them is reported.
+type importer struct {
+	g      *Graph
+	format string
+	errs   []error
+}
+
+func newImporter(format string) *importer {
+	return &importer{g: NewSoAGraph(nil), format: format}
+}
+
+func (im *importer) fail(format string, args ...any) {
+	im.errs = append(im.errs, ImportErr{Format: im.format, Reason: fmt.Sprintf(format, args...)})
+}
+
+// vertex adds a vertex from its textual attributes. Missing attributes are
+// empty, except the status which defaults to healthy and the last check
+// which defaults to the time of the import.
+func (im *importer) vertex(key string, attrs map[string]string) {
+	if key == "" {
+		im.fail("node without id")
+		return
+	}
+	if _, dup := im.g.lookup[key]; dup {
+		im.fail("duplicate node %q", key)
+		return
+	}
+
+	own := StatusHealthy
+	for _, name := range []string{"status", "own_status"} {
+		if text := attrs[name]; text != "" {
+			s, err := ParseHealthStatus(text)
+			if err != nil {
+				im.errs = append(im.errs, fmt.Errorf("node %q: %w", key, err))
+				return
+			}
+			own = s
+		}
+	}
+
+	var lastCheck int64
+	text := attrs["last_check"]
+	if text != "" {
+		ts, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			im.fail("node %q: last_check %q is not an integer", key, text)
+			return
+		}
+		lastCheck = ts
+	}
+
+	// addVertex stamps the vertex with the current time
+	id := im.g.addVertex(key, attrs["label"], attrs["class"], own)
+	if text != "" {
+		im.g.lastCheck[id] = lastCheck
+	}
+}
+
+// edge adds an edge from src to its dependency tgt, with the same checks as
+// AddEdge. Health is left to graph, which evaluates it once for all edges.
+func (im *importer) edge(src, tgt string) {
+	ksrc, ktgt, exists, err := im.g.checkEdge(src, tgt)
+	if err != nil {
+		im.errs = append(im.errs, err)
+		return
+	}
+	if !exists {
+		im.g.link(ksrc, ktgt)
+	}
+}
+
+// graph returns the imported graph, or every error found. Effective statuses
+// are computed from the own statuses once all edges are in place.
+func (im *importer) graph() (*Graph, error) {
+	if len(im.errs) > 0 {
+		err := errors.Join(im.errs...)
+		im.g.logger.Error("core.importer invalid input", slog.String("format", im.format), slog.Int("errors", len(im.errs)))
+		return nil, err
+	}
+
+	im.g.refreshAllHealth()
+	im.g.resetChanges()
+
+	return im.g, nil
+}
@@ -0,0 +1,192 @@
+package graphlib
+
+import (
+	"container/heap"
+	"log/slog"
+	"slices"
+)
+
+// TopologicalOrder returns every key with dependencies before their
+// dependents, the order in which services can be started. Among the
+// vertices ready at the same time the smallest key comes first.
+func (g *Graph) TopologicalOrder() ([]string, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.TopologicalOrder")
+
+	order, err := g.topologicalOrder(g.allIDs())
+	if err != nil {
+		return nil, err
+	}
+	return g.keysOf(order), nil
+}
+
+// TopologicalOrderFrom is TopologicalOrder restricted to key and its
+// transitive dependencies, key coming last.
+func (g *Graph) TopologicalOrderFrom(key string) ([]string, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.TopologicalOrderFrom", slog.String("key", key))
+
+	vs, err := g.dependencyClosure(key)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := g.topologicalOrder(vs)
+	if err != nil {
+		return nil, err
+	}
+	return g.keysOf(order), nil
+}
+
+// Layers groups the keys by depth: layer 0 holds the vertices without
+// dependencies and every other vertex sits one layer after its deepest
+// dependency. The vertices of a layer can be started in parallel once the
+// previous layers are up. Keys are sorted within a layer.
+func (g *Graph) Layers() [][]string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.Layers")
+
+	// the graph is a DAG, ordering it cannot fail
+	order, _ := g.topologicalOrder(g.allIDs())
+	return g.layers(order)
+}
+
+// LayersFrom is Layers restricted to key and its transitive dependencies.
+func (g *Graph) LayersFrom(key string) ([][]string, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.LayersFrom", slog.String("key", key))
+
+	vs, err := g.dependencyClosure(key)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := g.topologicalOrder(vs)
+	if err != nil {
+		return nil, err
+	}
+	return g.layers(order), nil
+}
+
+func (g *Graph) allIDs() []int {
+	vs := make([]int, len(g.labels))
+	for i := range vs {
+		vs[i] = i
+	}
+	return vs
+}
+
+// dependencyClosure returns key and every vertex it transitively depends
+// on. The dependencies of a member are always members.
+func (g *Graph) dependencyClosure(key string) ([]int, error) {
+	root, ok := g.lookup[key]
+	if !ok {
+		err := VertexNotFoundErr{Key: key}
+		g.logger.Error("core.Graph.dependencyClosure lookup error", slog.String("key", key), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	seen := map[int]struct{}{root: {}}
+	vs := []int{root}
+	for i := 0; i < len(vs); i++ {
+		for d := range g.dependencies[vs[i]] {
+			if _, ok := seen[d]; !ok {
+				seen[d] = struct{}{}
+				vs = append(vs, d)
+			}
+		}
+	}
+
+	return vs, nil
+}
+
+// topologicalOrder runs Kahn's algorithm over vs, which must contain the
+// dependencies of its members, taking the smallest ready key first.
+func (g *Graph) topologicalOrder(vs []int) ([]int, error) {
+	pending := make(map[int]int, len(vs))
+	ready := &keyHeap{keys: g.keys}
+
+	for _, v := range vs {
+		pending[v] = len(g.dependencies[v])
+		if pending[v] == 0 {
+			ready.ids = append(ready.ids, v)
+		}
+	}
+	heap.Init(ready)
+
+	order := make([]int, 0, len(vs))
+	for ready.Len() > 0 {
+		v := heap.Pop(ready).(int)
+		order = append(order, v)
+
+		for d := range g.dependents[v] {
+			if _, ok := pending[d]; !ok {
+				continue
+			}
+			pending[d]--
+			if pending[d] == 0 {
+				heap.Push(ready, d)
+			}
+		}
+	}
+
+	if len(order) < len(vs) {
+		// only reachable if the DAG invariant was broken
+		err := g.checkAcyclic()
+		g.logger.Error("core.Graph.topologicalOrder graph is not acyclic", slog.Any("err", err))
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// layers assigns every vertex of order, a topological order, one layer
+// past its deepest dependency.
+func (g *Graph) layers(order []int) [][]string {
+	depth := make(map[int]int, len(order))
+	var out [][]string
+
+	for _, v := range order {
+		d := 0
+		for dep := range g.dependencies[v] {
+			d = max(d, depth[dep]+1)
+		}
+		depth[v] = d
+
+		if d == len(out) {
+			out = append(out, nil)
+		}
+		out[d] = append(out[d], g.keys[v])
+	}
+
+	for _, layer := range out {
+		slices.Sort(layer)
+	}
+
+	return out
+}
+
+// keyHeap is a min-heap of vertex ids ordered by key.
+type keyHeap struct {
+	ids  []int
+	keys map[int]string
+}
+
+func (h *keyHeap) Len() int           { return len(h.ids) }
+func (h *keyHeap) Less(i, j int) bool { return h.keys[h.ids[i]] < h.keys[h.ids[j]] }
+func (h *keyHeap) Swap(i, j int)      { h.ids[i], h.ids[j] = h.ids[j], h.ids[i] }
+func (h *keyHeap) Push(x any)         { h.ids = append(h.ids, x.(int)) }
+
+func (h *keyHeap) Pop() any {
+	v := h.ids[len(h.ids)-1]
+	h.ids = h.ids[:len(h.ids)-1]
+	return v
+}
@@ -0,0 +1,82 @@
+package graphlib_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func TestTopologicalOrder(t *testing.T) {
+	g := buildGraph()
+
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"B", "E", "D", "C", "A", "F"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("want %v, got %v", want, order)
+	}
+
+	order, err = g.TopologicalOrderFrom("C")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"E", "D", "C"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("want %v, got %v", want, order)
+	}
+
+	var nf graphlib.VertexNotFoundErr
+	if _, err := g.TopologicalOrderFrom("X"); !errors.As(err, &nf) {
+		t.Fatalf("expected VertexNotFoundErr, got %v", err)
+	}
+}
+
+func TestLayers(t *testing.T) {
+	g := buildGraph()
+
+	if want, got := [][]string{{"B", "E"}, {"D"}, {"C", "F"}, {"A"}}, g.Layers(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+
+	layers, err := g.LayersFrom("A")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := [][]string{{"B", "E"}, {"D"}, {"C"}, {"A"}}; !reflect.DeepEqual(layers, want) {
+		t.Fatalf("want %v, got %v", want, layers)
+	}
+
+	if got := graphlib.NewSoAGraph(nil).Layers(); len(got) != 0 {
+		t.Fatalf("expected no layers, got %v", got)
+	}
+}
+
+func TestTopologicalOrder_Large(t *testing.T) {
+	g := graphlib.NewSoAGraph(nil)
+	for i := 0; i < 200; i++ {
+		g.AddVertex(string(rune('a'+i%26))+string(rune('a'+i/26)), "", "server", true)
+	}
+	order, _ := g.TopologicalOrder()
+	g.Batch(func(tx *graphlib.Tx) error {
+		for i := 1; i < len(order); i++ {
+			tx.AddEdge(order[i-1], order[i])
+		}
+		return nil
+	})
+
+	// a chain orders from its end
+	got, err := g.TopologicalOrder()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range got {
+		if got[i] != order[len(order)-1-i] {
+			t.Fatalf("unexpected order at %d: %v", i, got)
+		}
+	}
+	if n := len(g.Layers()); n != 200 {
+		t.Fatalf("expected 200 layers, got %d", n)
+	}
+}
@@ -0,0 +1,187 @@
+package graphlib
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// ShortestPath returns the keys of a path from src to tgt with the fewest
+// edges, following dependencies. Among paths of the same length the one
+// visiting smaller keys first wins.
+func (g *Graph) ShortestPath(src, tgt string) ([]string, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.ShortestPath", slog.String("src", src), slog.String("tgt", tgt))
+
+	ksrc, ktgt, err := g.lookupPair(src, tgt)
+	if err != nil {
+		return nil, err
+	}
+
+	path := shortestPath(ksrc, ktgt, g.sortedDependencies)
+	if path == nil {
+		return nil, VertexPathErr{Src: src, Dst: tgt}
+	}
+
+	return g.keysOf(path), nil
+}
+
+// KShortestPaths returns up to k distinct simple paths from src to tgt,
+// shortest first, found with Yen's algorithm. Paths of the same length are
+// ordered by their keys. A k below 1 asks for no path and returns none.
+func (g *Graph) KShortestPaths(src, tgt string, k int) ([][]Vertex, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.KShortestPaths", slog.String("src", src), slog.String("tgt", tgt), slog.Int("k", k))
+
+	ksrc, ktgt, err := g.lookupPair(src, tgt)
+	if err != nil || k <= 0 {
+		return nil, err
+	}
+
+	first := shortestPath(ksrc, ktgt, g.sortedDependencies)
+	if first == nil {
+		return nil, VertexPathErr{Src: src, Dst: tgt}
+	}
+
+	found := [][]int{first}
+	var candidates [][]int
+
+	for len(found) < k {
+		prev := found[len(found)-1]
+
+		for i := 0; i < len(prev)-1; i++ {
+			spur, root := prev[i], prev[:i+1]
+
+			// leave out the edges already taken from this root and the
+			// vertices of the root, so the spur path is new and simple
+			blockedEdges := make(map[edgeKey]struct{})
+			for _, p := range found {
+				if len(p) > i+1 && slices.Equal(p[:i+1], root) {
+					blockedEdges[edgeKey{p[i], p[i+1]}] = struct{}{}
+				}
+			}
+			blocked := make(map[int]struct{}, i)
+			for _, v := range root[:i] {
+				blocked[v] = struct{}{}
+			}
+
+			tail := shortestPath(spur, ktgt, func(v int, yield func(int)) {
+				g.sortedDependencies(v, func(d int) {
+					if _, ok := blocked[d]; ok {
+						return
+					}
+					if _, ok := blockedEdges[edgeKey{v, d}]; ok {
+						return
+					}
+					yield(d)
+				})
+			})
+			if tail == nil {
+				continue
+			}
+
+			path := append(slices.Clone(root[:i]), tail...)
+			if !slices.ContainsFunc(candidates, func(c []int) bool { return slices.Equal(c, path) }) {
+				candidates = append(candidates, path)
+			}
+		}
+
+		if len(candidates) == 0 {
+			break
+		}
+
+		best := 0
+		for j := range candidates {
+			if g.comparePaths(candidates[j], candidates[best]) < 0 {
+				best = j
+			}
+		}
+		found = append(found, candidates[best])
+		candidates = slices.Delete(candidates, best, best+1)
+	}
+
+	out := make([][]Vertex, min(k, len(found)))
+	index := g.newCauseIndex()
+	for i := range out {
+		out[i] = make([]Vertex, len(found[i]))
+		for j, id := range found[i] {
+			out[i][j] = g.vertex(id, index)
+		}
+	}
+
+	return out, nil
+}
+
+func (g *Graph) lookupPair(src, tgt string) (int, int, error) {
+	ksrc, ok := g.lookup[src]
+	if !ok {
+		return 0, 0, VertexNotFoundErr{Key: src}
+	}
+	ktgt, ok := g.lookup[tgt]
+	if !ok {
+		return 0, 0, VertexNotFoundErr{Key: tgt}
+	}
+	return ksrc, ktgt, nil
+}
+
+// sortedDependencies yields the dependencies of v ordered by key, so
+// searches break ties the same way every time.
+func (g *Graph) sortedDependencies(v int, yield func(int)) {
+	deps := make([]int, 0, len(g.dependencies[v]))
+	for d := range g.dependencies[v] {
+		deps = append(deps, d)
+	}
+	slices.SortFunc(deps, func(a, b int) int { return strings.Compare(g.keys[a], g.keys[b]) })
+
+	for _, d := range deps {
+		yield(d)
+	}
+}
+
+// comparePaths orders paths by length and then by their keys.
+func (g *Graph) comparePaths(a, b []int) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	for i := range a {
+		if c := strings.Compare(g.keys[a[i]], g.keys[b[i]]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// shortestPath returns the vertices of a shortest path from src to tgt,
+// both included, or nil when tgt cannot be reached.
+func shortestPath(src, tgt int, neighbors func(v int, yield func(int))) []int {
+	parent := map[int]int{src: src}
+	queue := []int{src}
+
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+
+		if v == tgt {
+			path := []int{tgt}
+			for v != src {
+				v = parent[v]
+				path = append(path, v)
+			}
+			slices.Reverse(path)
+			return path
+		}
+
+		neighbors(v, func(d int) {
+			if _, seen := parent[d]; !seen {
+				parent[d] = v
+				queue = append(queue, d)
+			}
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package graphlib_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func pathKeys(paths [][]graphlib.Vertex) [][]string {
+	out := make([][]string, len(paths))
+	for i, p := range paths {
+		out[i] = keys(p)
+	}
+	return out
+}
+
+func TestShortestPath(t *testing.T) {
+	g := buildGraph()
+
+	path, err := g.ShortestPath("A", "E")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"A", "C", "D", "E"}; !reflect.DeepEqual(path, want) {
+		t.Fatalf("want %v, got %v", want, path)
+	}
+
+	g.AddEdge("A", "D")
+	if path, _ := g.ShortestPath("A", "E"); !reflect.DeepEqual(path, []string{"A", "D", "E"}) {
+		t.Fatalf("expected the direct edge to win, got %v", path)
+	}
+
+	if path, _ := g.ShortestPath("B", "B"); !reflect.DeepEqual(path, []string{"B"}) {
+		t.Fatalf("expected a single vertex path, got %v", path)
+	}
+
+	var perr graphlib.VertexPathErr
+	if _, err := g.ShortestPath("E", "A"); !errors.As(err, &perr) {
+		t.Fatalf("expected VertexPathErr, got %v", err)
+	}
+	var nf graphlib.VertexNotFoundErr
+	if _, err := g.ShortestPath("A", "X"); !errors.As(err, &nf) {
+		t.Fatalf("expected VertexNotFoundErr, got %v", err)
+	}
+}
+
+func TestKShortestPaths(t *testing.T) {
+	g := buildGraph()
+	g.AddEdge("A", "D")
+	g.AddEdge("B", "D")
+	g.SetVertexStatus("E", graphlib.StatusDegraded)
+
+	paths, err := g.KShortestPaths("A", "E", 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := [][]string{{"A", "D", "E"}, {"A", "B", "D", "E"}, {"A", "C", "D", "E"}}
+	if got := pathKeys(paths); !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	if last := paths[0][2]; last.Status != graphlib.StatusDegraded {
+		t.Fatalf("expected full vertices, got %+v", last)
+	}
+
+	paths, _ = g.KShortestPaths("A", "E", 2)
+	if got := pathKeys(paths); !reflect.DeepEqual(got, want[:2]) {
+		t.Fatalf("want %v, got %v", want[:2], got)
+	}
+
+	var perr graphlib.VertexPathErr
+	if _, err := g.KShortestPaths("F", "A", 3); !errors.As(err, &perr) {
+		t.Fatalf("expected VertexPathErr, got %v", err)
+	}
+
+	for _, k := range []int{0, -1} {
+		if paths, err := g.KShortestPaths("A", "E", k); err != nil || len(paths) != 0 {
+			t.Fatalf("k=%d: expected no paths, got %v %v", k, pathKeys(paths), err)
+		}
+	}
+	var nerr graphlib.VertexNotFoundErr
+	if _, err := g.KShortestPaths("X", "E", -1); !errors.As(err, &nerr) {
+		t.Fatalf("expected VertexNotFoundErr, got %v", err)
+	}
+}
+
+func TestKShortestPaths_Grid(t *testing.T) {
+	// a 4x4 grid where every vertex depends on its right and lower
+	// neighbours has 20 monotone paths from corner to corner, all of length 7
+	g := graphlib.NewSoAGraph(nil)
+	key := func(r, c int) string { return string(rune('a'+r)) + string(rune('0'+c)) }
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			g.AddVertex(key(r, c), "", "cell", true)
+		}
+	}
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			if c < 3 {
+				g.AddEdge(key(r, c), key(r, c+1))
+			}
+			if r < 3 {
+				g.AddEdge(key(r, c), key(r+1, c))
+			}
+		}
+	}
+
+	paths, err := g.KShortestPaths("a0", "d3", 50)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 20 {
+		t.Fatalf("expected 20 paths, got %d", len(paths))
+	}
+
+	seen := map[string]bool{}
+	for _, p := range pathKeys(paths) {
+		if len(p) != 7 {
+			t.Fatalf("unexpected path %v", p)
+		}
+		s := ""
+		for _, k := range p {
+			s += k
+		}
+		if seen[s] {
+			t.Fatalf("duplicate path %v", p)
+		}
+		seen[s] = true
+	}
+}
@@ -0,0 +1,128 @@
+package graphlib
+
+import (
+	"log/slog"
+)
+
+type PolicyMode uint8
+
+const (
+	// PolicyAny fails a vertex as soon as one dependency fails.
+	PolicyAny PolicyMode = iota
+	// PolicyAll fails a vertex only when every dependency fails.
+	PolicyAll
+	// PolicyQuorum fails a vertex when fewer than Quorum dependencies are
+	// healthy.
+	PolicyQuorum
+	// PolicyPercent fails a vertex when at least Percent percent of its
+	// dependencies fail.
+	PolicyPercent
+)
+
+// PropagationPolicy decides whether failing dependencies are passed on to a
+// vertex. When they are, the vertex inherits the worst status imposed by
+// its failing dependencies.
+type PropagationPolicy struct {
+	Mode    PolicyMode
+	Quorum  int
+	Percent float64
+}
+
+func (p PropagationPolicy) validate() error {
+	switch p.Mode {
+	case PolicyAny, PolicyAll:
+		return nil
+	case PolicyQuorum:
+		if p.Quorum < 1 {
+			return InvalidPolicyErr{Reason: "quorum must be at least 1"}
+		}
+		return nil
+	case PolicyPercent:
+		if p.Percent <= 0 || p.Percent > 100 {
+			return InvalidPolicyErr{Reason: "percent must be in (0, 100]"}
+		}
+		return nil
+	}
+
+	return InvalidPolicyErr{Reason: "unknown policy mode"}
+}
+
+// apply returns the inherited status of a vertex with total dependencies,
+// failing of which impose something other than StatusHealthy and worst
+// being the worst status imposed.
+func (p PropagationPolicy) apply(total, failing int, worst HealthStatus) HealthStatus {
+	if failing == 0 {
+		return StatusHealthy
+	}
+
+	var tripped bool
+	switch p.Mode {
+	case PolicyAny:
+		tripped = true
+	case PolicyAll:
+		tripped = failing == total
+	case PolicyQuorum:
+		tripped = total-failing < p.Quorum
+	case PolicyPercent:
+		tripped = float64(failing)*100 >= p.Percent*float64(total)
+	}
+
+	if !tripped {
+		return StatusHealthy
+	}
+
+	return worst
+}
+
+// SetClassPolicy sets the propagation policy of every vertex of class that
+// has no policy of its own, including vertices added later.
+func (g *Graph) SetClassPolicy(class string, p PropagationPolicy) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger.Debug("core.Graph.SetClassPolicy", slog.String("class", class), slog.Int("mode", int(p.Mode)))
+
+	if err := p.validate(); err != nil {
+		g.logger.Error("core.Graph.SetClassPolicy invalid policy", slog.String("class", class), slog.String("err", err.Error()))
+		return err
+	}
+
+	g.classPolicy[g.classIndex(class)] = p
+	g.refreshAllHealth()
+
+	return nil
+}
+
+// SetVertexPolicy sets the propagation policy of a single vertex. It takes
+// precedence over the policy of the vertex class.
+func (g *Graph) SetVertexPolicy(key string, p PropagationPolicy) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger.Debug("core.Graph.SetVertexPolicy", slog.String("key", key), slog.Int("mode", int(p.Mode)))
+
+	v, ok := g.lookup[key]
+	if !ok {
+		err := VertexNotFoundErr{Key: key}
+		g.logger.Error("core.Graph.SetVertexPolicy lookup error", slog.String("key", key), slog.String("err", err.Error()))
+		return err
+	}
+
+	if err := p.validate(); err != nil {
+		g.logger.Error("core.Graph.SetVertexPolicy invalid policy", slog.String("key", key), slog.String("err", err.Error()))
+		return err
+	}
+
+	g.vertexPolicy[v] = p
+	g.refreshHealth(key, v)
+
+	return nil
+}
+
+func (g *Graph) policyFor(v int) PropagationPolicy {
+	if p, ok := g.vertexPolicy[v]; ok {
+		return p
+	}
+
+	return g.classPolicy[g.classes[v]]
+}
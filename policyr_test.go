@@ -0,0 +1,142 @@
+package graphlib_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+// app -> lb -> r1..r5
+func buildPool() *graphlib.Graph {
+	g := graphlib.NewSoAGraph(nil)
+	g.AddVertex("app", "app", "service", true)
+	g.AddVertex("lb", "lb", "pool", true)
+	g.AddEdge("app", "lb")
+	for i := 1; i <= 5; i++ {
+		k := fmt.Sprintf("r%d", i)
+		g.AddVertex(k, k, "replica", true)
+		g.AddEdge("lb", k)
+	}
+	return g
+}
+
+func status(t *testing.T, g *graphlib.Graph, key string) graphlib.HealthStatus {
+	t.Helper()
+	v, err := g.GetVertex(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return v.Status
+}
+
+func TestClassPolicy_Quorum(t *testing.T) {
+	g := buildPool()
+
+	err := g.SetClassPolicy("pool", graphlib.PropagationPolicy{Mode: graphlib.PolicyQuorum, Quorum: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	g.SetVertexHealth("r1", false)
+	g.SetVertexHealth("r2", false)
+	if s := status(t, g, "app"); s != graphlib.StatusHealthy {
+		t.Fatalf("want app healthy with 3 of 5 replicas up, got %v", s)
+	}
+
+	// the dead replicas are still root causes, but the pool masks them
+	if rc := g.AllRootCauses(); len(rc) != 2 || len(rc[0].Impacted) != 0 {
+		t.Fatalf("expected dead replicas to impact nothing, got %v", rc)
+	}
+
+	g.SetVertexHealth("r3", false)
+	if s := status(t, g, "app"); s != graphlib.StatusUnhealthy {
+		t.Fatalf("want app unhealthy with 2 of 5 replicas up, got %v", s)
+	}
+
+	rc, _ := g.RootCauses("app")
+	if got := keys(rc); !equalKeys(got, []string{"r1", "r2", "r3"}) {
+		t.Fatalf("root causes mismatch got=%v want=[r1 r2 r3]", got)
+	}
+
+	g.SetVertexHealth("r1", true)
+	if s := status(t, g, "app"); s != graphlib.StatusHealthy {
+		t.Fatalf("want app to recover with 3 of 5 replicas up, got %v", s)
+	}
+}
+
+func TestClassPolicy_AllAndPercent(t *testing.T) {
+	g := buildPool()
+	g.SetClassPolicy("pool", graphlib.PropagationPolicy{Mode: graphlib.PolicyAll})
+
+	for i := 1; i <= 4; i++ {
+		g.SetVertexHealth(fmt.Sprintf("r%d", i), false)
+	}
+	if s := status(t, g, "lb"); s != graphlib.StatusHealthy {
+		t.Fatalf("want lb healthy with one replica up, got %v", s)
+	}
+
+	g.SetVertexStatus("r5", graphlib.StatusDegraded)
+	if s := status(t, g, "lb"); s != graphlib.StatusUnhealthy {
+		t.Fatalf("want lb unhealthy with every replica failing, got %v", s)
+	}
+
+	g.ClearHealthyStatus()
+	g.SetClassPolicy("pool", graphlib.PropagationPolicy{Mode: graphlib.PolicyPercent, Percent: 40})
+
+	g.SetVertexHealth("r1", false)
+	if s := status(t, g, "lb"); s != graphlib.StatusHealthy {
+		t.Fatalf("want lb healthy with 20%% of replicas down, got %v", s)
+	}
+
+	g.SetVertexHealth("r2", false)
+	if s := status(t, g, "lb"); s != graphlib.StatusUnhealthy {
+		t.Fatalf("want lb unhealthy with 40%% of replicas down, got %v", s)
+	}
+}
+
+func TestVertexPolicy(t *testing.T) {
+	g := buildPool()
+	g.SetClassPolicy("pool", graphlib.PropagationPolicy{Mode: graphlib.PolicyAll})
+
+	err := g.SetVertexPolicy("lb", graphlib.PropagationPolicy{Mode: graphlib.PolicyAny})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	g.SetVertexHealth("r4", false)
+	if s := status(t, g, "app"); s != graphlib.StatusUnhealthy {
+		t.Fatalf("want vertex policy to take precedence, got %v", s)
+	}
+
+	// the vertex policy follows the vertex when the SoA arrays are compacted
+	g.RemoveVertex("app")
+	g.SetVertexHealth("r4", true)
+	g.SetVertexHealth("r5", false)
+	if s := status(t, g, "lb"); s != graphlib.StatusUnhealthy {
+		t.Fatalf("want lb unhealthy, got %v", s)
+	}
+
+	err = g.SetVertexPolicy("X", graphlib.PropagationPolicy{})
+	var nf graphlib.VertexNotFoundErr
+	if !errors.As(err, &nf) {
+		t.Fatalf("expected VertexNotFoundErr, got %v", err)
+	}
+}
+
+func TestInvalidPolicy(t *testing.T) {
+	g := buildPool()
+
+	for _, p := range []graphlib.PropagationPolicy{
+		{Mode: graphlib.PolicyQuorum},
+		{Mode: graphlib.PolicyPercent, Percent: 120},
+		{Mode: graphlib.PolicyMode(9)},
+	} {
+		err := g.SetClassPolicy("pool", p)
+		var pErr graphlib.InvalidPolicyErr
+		if !errors.As(err, &pErr) {
+			t.Fatalf("expected InvalidPolicyErr for %+v, got %v", p, err)
+		}
+	}
+}
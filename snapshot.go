@@ -0,0 +1,163 @@
+package graphlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+)
+
+const snapshotVersion = 1
+
+type snapshot struct {
+	Version  int              `json:"version"`
+	Classes  []string         `json:"classes"`
+	Vertices []snapshotVertex `json:"vertices"`
+	Edges    []snapshotEdge   `json:"edges"`
+}
+
+type snapshotVertex struct {
+	Key       string       `json:"key"`
+	Label     string       `json:"label"`
+	Class     int          `json:"class"` // index into snapshot.Classes
+	Status    HealthStatus `json:"status"`
+	OwnStatus HealthStatus `json:"own_status"`
+	LastCheck int64        `json:"last_check"`
+}
+
+type snapshotEdge struct {
+	Src string `json:"src"`
+	Tgt string `json:"tgt"`
+}
+
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.MarshalJSON")
+
+	return json.Marshal(g.snapshot())
+}
+
+// WriteSnapshot writes the vertices, the class table and the edges of the
+// graph as JSON. The output is deterministic for a given graph.
+func (g *Graph) WriteSnapshot(w io.Writer) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.WriteSnapshot")
+
+	return json.NewEncoder(w).Encode(g.snapshot())
+}
+
+// LoadSnapshot builds a graph from the output of WriteSnapshot. Edges go
+// through the same checks as AddEdge, so a snapshot that is not a DAG is
+// rejected with CycleErr or BidirectionalEdgeErr.
+func LoadSnapshot(r io.Reader) (*Graph, error) {
+	var s snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, SnapshotErr{Reason: err.Error()}
+	}
+
+	if s.Version != snapshotVersion {
+		return nil, SnapshotErr{Reason: fmt.Sprintf("unsupported version %d", s.Version)}
+	}
+
+	g := NewSoAGraph(nil)
+	if err := g.restore(s); err != nil {
+		g.logger.Error("core.LoadSnapshot restore error", slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	// like a binary snapshot, a loaded graph starts with an empty change feed
+	g.resetChanges()
+
+	return g, nil
+}
+
+func (g *Graph) snapshot() snapshot {
+	s := snapshot{
+		Version:  snapshotVersion,
+		Classes:  make([]string, len(g.classLookup)),
+		Vertices: make([]snapshotVertex, len(g.labels)),
+		Edges:    make([]snapshotEdge, 0, len(g.dependencies)),
+	}
+
+	for i, class := range g.classLookup {
+		s.Classes[i] = class
+	}
+
+	for i := range g.labels {
+		s.Vertices[i] = snapshotVertex{
+			Key:       g.keys[i],
+			Label:     g.labels[i],
+			Class:     g.classes[i],
+			Status:    g.status[i],
+			OwnStatus: g.ownStatus[i],
+			LastCheck: g.lastCheck[i],
+		}
+	}
+
+	for src, outs := range g.dependencies {
+		for tgt := range outs {
+			s.Edges = append(s.Edges, snapshotEdge{Src: g.keys[src], Tgt: g.keys[tgt]})
+		}
+	}
+
+	sort.Slice(s.Edges, func(i, j int) bool {
+		if s.Edges[i].Src != s.Edges[j].Src {
+			return s.Edges[i].Src < s.Edges[j].Src
+		}
+		return s.Edges[i].Tgt < s.Edges[j].Tgt
+	})
+
+	return s
+}
+
+// restore loads s into an empty graph.
+func (g *Graph) restore(s snapshot) error {
+	for _, class := range s.Classes {
+		g.classIndex(class)
+	}
+
+	for _, v := range s.Vertices {
+		if v.Class < 0 || v.Class >= len(s.Classes) {
+			return SnapshotErr{Reason: fmt.Sprintf("vertex %q has unknown class %d", v.Key, v.Class)}
+		}
+		if _, dup := g.lookup[v.Key]; dup {
+			return SnapshotErr{Reason: fmt.Sprintf("duplicate vertex %q", v.Key)}
+		}
+		if !v.Status.valid() || !v.OwnStatus.valid() {
+			return InvalidHealthStatusErr{Status: fmt.Sprintf("%s/%s", v.Status, v.OwnStatus)}
+		}
+
+		id := g.addVertex(v.Key, v.Label, s.Classes[v.Class], v.OwnStatus)
+		g.status[id] = v.Status
+		g.lastCheck[id] = v.LastCheck
+	}
+
+	// the saved statuses are kept as they are, edges only need checking
+	for _, e := range s.Edges {
+		src, tgt, exists, err := g.checkEdge(e.Src, e.Tgt)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			g.link(src, tgt)
+		}
+	}
+
+	return nil
+}
+
+// clone returns a copy of the topology and statuses of g, for work that
+// must not touch g. Configuration, subscribers and the change feed are not
+// copied.
+func (g *Graph) clone() (*Graph, error) {
+	c := NewSoAGraph(g.logger)
+	if err := c.restore(g.snapshot()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,107 @@
+package graphlib_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	g := buildGraph()
+	g.AddVertex("G", "gee", "database", true)
+	g.AddEdge("E", "G")
+	g.SetVertexStatus("G", graphlib.StatusDegraded)
+	g.SetVertexHealth("C", false)
+
+	var buf bytes.Buffer
+	if err := g.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	raw, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(buf.Bytes()), raw) {
+		t.Fatalf("MarshalJSON and WriteSnapshot differ:\n%s\n%s", raw, buf.Bytes())
+	}
+
+	loaded, err := graphlib.LoadSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, k := range []string{"A", "B", "C", "D", "E", "F", "G"} {
+		want, _ := g.GetVertex(k)
+		got, err := loaded.GetVertex(k)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatalf("vertex %s: want %+v, got %+v", k, want, got)
+		}
+	}
+
+	want, _ := g.VertexDependencies("A", true)
+	got, _ := loaded.VertexDependencies("A", true)
+	if !reflect.DeepEqual(setEdges(want.Edges), setEdges(got.Edges)) {
+		t.Fatalf("edges mismatch got=%v want=%v", got.Edges, want.Edges)
+	}
+
+	if ws, gs := g.Stats(), loaded.Stats(); ws.TotalEdges != gs.TotalEdges || ws.TotalUnhealthyVertices != gs.TotalUnhealthyVertices {
+		t.Fatalf("stats mismatch got=%+v want=%+v", gs, ws)
+	}
+
+	// health keeps propagating after a load
+	loaded.SetVertexHealth("G", true)
+	if v, _ := loaded.GetVertex("D"); !v.Healthy {
+		t.Fatalf("expected D to recover, got %+v", v)
+	}
+}
+
+func TestLoadSnapshot_Invalid(t *testing.T) {
+	vertices := `"vertices":[
+		{"key":"A","class":0,"status":"healthy","own_status":"healthy"},
+		{"key":"B","class":0,"status":"healthy","own_status":"healthy"},
+		{"key":"C","class":0,"status":"healthy","own_status":"healthy"}]`
+
+	for name, tc := range map[string]struct {
+		doc  string
+		want any
+	}{
+		"cycle": {
+			doc:  `{"version":1,"classes":["server"],` + vertices + `,"edges":[{"src":"A","tgt":"B"},{"src":"B","tgt":"C"},{"src":"C","tgt":"A"}]}`,
+			want: &graphlib.CycleErr{},
+		},
+		"bidirectional": {
+			doc:  `{"version":1,"classes":["server"],` + vertices + `,"edges":[{"src":"A","tgt":"B"},{"src":"B","tgt":"A"}]}`,
+			want: &graphlib.BidirectionalEdgeErr{},
+		},
+		"unknown vertex": {
+			doc:  `{"version":1,"classes":["server"],` + vertices + `,"edges":[{"src":"A","tgt":"X"}]}`,
+			want: &graphlib.VertexNotFoundErr{},
+		},
+		"status": {
+			doc:  `{"version":1,"classes":["server"],"vertices":[{"key":"A","class":0,"status":"broken"}]}`,
+			want: &graphlib.SnapshotErr{},
+		},
+		"class": {
+			doc:  `{"version":1,"classes":[],` + vertices + `}`,
+			want: &graphlib.SnapshotErr{},
+		},
+		"version": {
+			doc:  `{"version":99}`,
+			want: &graphlib.SnapshotErr{},
+		},
+	} {
+		_, err := graphlib.LoadSnapshot(strings.NewReader(tc.doc))
+		if err == nil || !errors.As(err, tc.want) {
+			t.Fatalf("%s: expected %T, got %v", name, tc.want, err)
+		}
+	}
+}
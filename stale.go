@@ -0,0 +1,137 @@
+package graphlib
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SetStaleTTL sets how long a reported status stays valid. Vertices whose
+// last check is older than the TTL are stale. A zero TTL disables staleness
+// for every class without a TTL of its own.
+func (g *Graph) SetStaleTTL(ttl time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger.Debug("core.Graph.SetStaleTTL", slog.Duration("ttl", ttl))
+
+	g.staleTTL = ttl
+}
+
+// SetClassStaleTTL overrides the global TTL for the vertices of class. A
+// zero TTL disables staleness for the class.
+func (g *Graph) SetClassStaleTTL(class string, ttl time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger.Debug("core.Graph.SetClassStaleTTL", slog.String("class", class), slog.Duration("ttl", ttl))
+
+	g.classTTL[g.classIndex(class)] = ttl
+}
+
+// StaleVertices returns the vertices whose last check is older than their
+// TTL at now, expressed in nanoseconds like LastCheck.
+func (g *Graph) StaleVertices(now int64) []Vertex {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.StaleVertices", slog.Int64("now", now))
+
+	out := make([]Vertex, 0, 8)
+	index := g.newCauseIndex()
+	for v := range g.status {
+		if g.isStale(v, now) {
+			out = append(out, g.vertex(v, index))
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+
+	return out
+}
+
+// SweepStale moves every stale vertex to StatusUnknown and propagates the
+// change. The last check is left untouched, so the vertex stays stale until
+// it reports again. It returns the keys of the vertices it changed. Health
+// events name the stale vertex as cause when it is the only one swept, and
+// have no cause otherwise.
+func (g *Graph) SweepStale() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.nowFn()
+	g.logger.Debug("core.Graph.SweepStale", slog.Int64("now", now))
+
+	var stale []int
+	for v := range g.ownStatus {
+		if g.ownStatus[v] != StatusUnknown && g.isStale(v, now) {
+			g.ownStatus[v] = StatusUnknown
+			stale = append(stale, v)
+		}
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+
+	// a single pass evaluates shared dependents once; with several stale
+	// vertices there is no single cause to report
+	cause := ""
+	if len(stale) == 1 {
+		cause = g.keys[stale[0]]
+	}
+	g.refreshHealth(cause, stale...)
+
+	out := g.keysOf(stale)
+	sort.Strings(out)
+
+	g.logger.Info("core.Graph.SweepStale stale vertices moved to unknown", slog.Int("count", len(out)))
+
+	return out
+}
+
+// StartStaleSweeper runs SweepStale every interval until stop is called.
+func (g *Graph) StartStaleSweeper(interval time.Duration) (stop func()) {
+	g.logger.Debug("core.Graph.StartStaleSweeper", slog.Duration("interval", interval))
+
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				g.SweepStale()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+func (g *Graph) ttlFor(v int) time.Duration {
+	if ttl, ok := g.classTTL[g.classes[v]]; ok {
+		return ttl
+	}
+
+	return g.staleTTL
+}
+
+// isStale reports whether the last check of v is older than its TTL.
+// Vertices under maintenance are not expected to report and never go stale.
+func (g *Graph) isStale(v int, now int64) bool {
+	if g.ownStatus[v] == StatusMaintenance {
+		return false
+	}
+
+	ttl := g.ttlFor(v)
+
+	return ttl > 0 && now-g.lastCheck[v] > int64(ttl)
+}
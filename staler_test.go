@@ -0,0 +1,118 @@
+package graphlib
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ now int64 }
+
+func (c *fakeClock) advance(d time.Duration) { c.now += int64(d) }
+
+func newStaleGraph() (*Graph, *fakeClock) {
+	clock := &fakeClock{now: int64(time.Hour)}
+
+	g := NewSoAGraph(nil)
+	g.nowFn = func() int64 { return clock.now }
+
+	g.AddVertex("app", "app", "service", true)
+	g.AddVertex("db", "db", "database", true)
+	g.AddVertex("batch", "batch", "job", true)
+	g.AddEdge("app", "db")
+
+	return g, clock
+}
+
+func TestStaleVertices(t *testing.T) {
+	g, clock := newStaleGraph()
+
+	if got := g.StaleVertices(clock.now); len(got) != 0 {
+		t.Fatalf("Expected no stale vertices without a TTL, but got %v", got)
+	}
+
+	g.SetStaleTTL(time.Minute)
+	g.SetClassStaleTTL("job", 0)
+	g.SetClassStaleTTL("database", 10*time.Minute)
+
+	clock.advance(2 * time.Minute)
+	g.SetVertexHealth("app", true)
+
+	if got := g.StaleVertices(clock.now); len(got) != 0 {
+		t.Fatalf("Expected no stale vertices, but got %v", got)
+	}
+
+	got := g.StaleVertices(clock.now + int64(time.Minute) + 1)
+	if len(got) != 1 || got[0].Key != "app" {
+		t.Fatalf("Expected app to be stale, but got %v", got)
+	}
+
+	clock.advance(9 * time.Minute)
+	stats := g.Stats()
+	if stats.TotalStaleVertices != 2 || len(stats.StaleVertices) != 2 {
+		t.Fatalf("Expected app and db to be stale, but got %+v", stats.StaleVertices)
+	}
+	for _, v := range stats.StaleVertices {
+		if v.Key == "batch" {
+			t.Fatal("Expected batch to never go stale")
+		}
+	}
+}
+
+func TestSweepStale(t *testing.T) {
+	g, clock := newStaleGraph()
+	g.SetStaleTTL(time.Minute)
+	g.SetVertexStatus("batch", StatusMaintenance)
+
+	clock.advance(time.Minute)
+	if swept := g.SweepStale(); swept != nil {
+		t.Fatalf("Expected nothing to be swept, but got %v", swept)
+	}
+
+	clock.advance(time.Second)
+	g.SetVertexHealth("app", true)
+
+	swept := g.SweepStale()
+	if len(swept) != 1 || swept[0] != "db" {
+		t.Fatalf("Expected db to be swept, but got %v", swept)
+	}
+
+	db, _ := g.GetVertex("db")
+	if db.Status != StatusUnknown {
+		t.Fatalf("Expected db to be unknown, but got %v", db.Status)
+	}
+
+	g.SetPropagationRule(StatusUnknown, StatusDegraded)
+	if app, _ := g.GetVertex("app"); app.Status != StatusDegraded {
+		t.Fatalf("Expected app to be degraded by a stale dependency, but got %v", app.Status)
+	}
+
+	// a fresh report brings the vertex back
+	g.SetVertexHealth("db", true)
+	if app, _ := g.GetVertex("app"); app.Status != StatusHealthy {
+		t.Fatalf("Expected app to recover, but got %v", app.Status)
+	}
+}
+
+func TestStaleSweeper(t *testing.T) {
+	g, clock := newStaleGraph()
+	g.SetStaleTTL(time.Minute)
+
+	g.mu.Lock()
+	clock.advance(time.Hour)
+	g.mu.Unlock()
+
+	stop := g.StartStaleSweeper(time.Millisecond)
+	defer stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if g.Stats().TotalUnknownVertices == 3 {
+			stop()
+			stop()
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatal("Expected the sweeper to mark every vertex as unknown")
+}
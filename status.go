@@ -0,0 +1,99 @@
+package graphlib
+
+import "strings"
+
+type HealthStatus uint8
+
+const (
+	StatusUnknown HealthStatus = iota
+	StatusHealthy
+	StatusDegraded
+	StatusUnhealthy
+	StatusMaintenance
+)
+
+var statusNames = [...]string{
+	StatusUnknown:     "unknown",
+	StatusHealthy:     "healthy",
+	StatusDegraded:    "degraded",
+	StatusUnhealthy:   "unhealthy",
+	StatusMaintenance: "maintenance",
+}
+
+// severity orders the statuses from best to worst when several of them
+// compete for the effective status of a vertex.
+var severity = [...]int{
+	StatusHealthy:     0,
+	StatusMaintenance: 1,
+	StatusUnknown:     2,
+	StatusDegraded:    3,
+	StatusUnhealthy:   4,
+}
+
+func ParseHealthStatus(s string) (HealthStatus, error) {
+	for i, name := range statusNames {
+		if strings.EqualFold(s, name) {
+			return HealthStatus(i), nil
+		}
+	}
+
+	return StatusUnknown, InvalidHealthStatusErr{Status: s}
+}
+
+func (s HealthStatus) String() string {
+	if !s.valid() {
+		return "invalid"
+	}
+	return statusNames[s]
+}
+
+func (s HealthStatus) MarshalText() ([]byte, error) {
+	if !s.valid() {
+		return nil, InvalidHealthStatusErr{Status: s.String()}
+	}
+	return []byte(statusNames[s]), nil
+}
+
+func (s *HealthStatus) UnmarshalText(text []byte) error {
+	st, err := ParseHealthStatus(string(text))
+	if err != nil {
+		return err
+	}
+	*s = st
+	return nil
+}
+
+func (s HealthStatus) valid() bool {
+	return int(s) < len(statusNames)
+}
+
+// impaired reports whether the status describes a failure.
+func (s HealthStatus) impaired() bool {
+	return s == StatusDegraded || s == StatusUnhealthy
+}
+
+func worse(a, b HealthStatus) HealthStatus {
+	if severity[b] > severity[a] {
+		return b
+	}
+	return a
+}
+
+func statusOf(healthy bool) HealthStatus {
+	if healthy {
+		return StatusHealthy
+	}
+	return StatusUnhealthy
+}
+
+// defaultPropagation maps the status of a dependency to the status it
+// imposes on its dependents.
+func defaultPropagation() map[HealthStatus]HealthStatus {
+	return map[HealthStatus]HealthStatus{
+		StatusUnknown:     StatusHealthy,
+		StatusHealthy:     StatusHealthy,
+		StatusDegraded:    StatusDegraded,
+		StatusUnhealthy:   StatusUnhealthy,
+		StatusMaintenance: StatusHealthy,
+	}
+}
@@ -0,0 +1,139 @@
+package graphlib
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// TraversalOptions configures VertexDependenciesWith and
+// VertexDependentsWith. Zero values mean no limit and no filter.
+type TraversalOptions struct {
+	MaxDepth int // hops from the root
+
+	// ClassFilter and HealthFilter select the vertices returned, matched
+	// against Class and the effective status. The traversal still goes
+	// through the vertices they leave out. The root is always returned.
+	ClassFilter  []string
+	HealthFilter []HealthStatus
+
+	Limit int // vertices returned, root included, nearest first
+}
+
+// VertexDependenciesWith walks the dependencies of key breadth first, so
+// each vertex is found at its smallest number of hops. Vertices are
+// returned by depth and then key, with the edges walked between them.
+func (g *Graph) VertexDependenciesWith(key string, opts TraversalOptions) (Traversal, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.VertexDependenciesWith", slog.String("key", key), slog.Int("max_depth", opts.MaxDepth))
+
+	return g.traverse(key, opts, g.dependencies, false)
+}
+
+// VertexDependentsWith is VertexDependenciesWith walking the dependents of
+// key instead.
+func (g *Graph) VertexDependentsWith(key string, opts TraversalOptions) (Traversal, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	g.logger.Debug("core.Graph.VertexDependentsWith", slog.String("key", key), slog.Int("max_depth", opts.MaxDepth))
+
+	return g.traverse(key, opts, g.dependents, true)
+}
+
+// traverse walks adjacency from key. reversed tells that adjacency holds
+// dependents, whose edges point back at the vertex walked from.
+func (g *Graph) traverse(key string, opts TraversalOptions, adjacency map[int]map[int]struct{}, reversed bool) (Traversal, error) {
+	rootID, ok := g.lookup[key]
+	if !ok {
+		return Traversal{}, VertexNotFoundErr{Key: key}
+	}
+
+	classes := toSet(opts.ClassFilter)
+	statuses := toSet(opts.HealthFilter)
+	keep := func(v int) bool {
+		if classes != nil {
+			if _, ok := classes[g.classLookup[g.classes[v]]]; !ok {
+				return false
+			}
+		}
+		if statuses != nil {
+			if _, ok := statuses[g.status[v]]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	depth := map[int]int{rootID: 0}
+	kept := []int{rootID}
+	walked := make([]edgeKey, 0, 8)
+
+	// level by level, sorted by key, so that Limit keeps the nearest
+	// vertices and ties are always broken the same way
+	level := []int{rootID}
+	for d := 1; len(level) > 0 && (opts.MaxDepth <= 0 || d <= opts.MaxDepth); d++ {
+		var next []int
+		for _, v := range level {
+			for n := range adjacency[v] {
+				if reversed {
+					walked = append(walked, edgeKey{n, v})
+				} else {
+					walked = append(walked, edgeKey{v, n})
+				}
+				if _, seen := depth[n]; !seen {
+					depth[n] = d
+					next = append(next, n)
+				}
+			}
+		}
+
+		slices.SortFunc(next, func(a, b int) int { return strings.Compare(g.keys[a], g.keys[b]) })
+		for _, n := range next {
+			if keep(n) {
+				kept = append(kept, n)
+			}
+		}
+		level = next
+	}
+
+	if opts.Limit > 0 && len(kept) > opts.Limit {
+		kept = kept[:opts.Limit]
+	}
+
+	out := Traversal{
+		Subgraph: Subgraph{Vertices: make([]Vertex, len(kept)), Edges: make([]Edge, 0, len(kept))},
+		Depth:    make(map[string]int, len(kept)),
+	}
+	in := make(map[int]struct{}, len(kept))
+	index := g.newCauseIndex()
+	for i, v := range kept {
+		out.Vertices[i] = g.vertex(v, index)
+		out.Depth[g.keys[v]] = depth[v]
+		in[v] = struct{}{}
+	}
+
+	for _, e := range walked {
+		_, src := in[e.src]
+		_, tgt := in[e.tgt]
+		if src && tgt {
+			out.Edges = append(out.Edges, Edge{
+				Key:    fmt.Sprintf("%s-%s", g.keys[e.src], g.keys[e.tgt]),
+				Source: g.keys[e.src],
+				Target: g.keys[e.tgt],
+			})
+		}
+	}
+
+	slices.SortFunc(out.Edges, func(a, b Edge) int {
+		if c := strings.Compare(a.Source, b.Source); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Target, b.Target)
+	})
+
+	return out, nil
+}
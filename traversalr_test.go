@@ -0,0 +1,80 @@
+package graphlib_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func TestVertexDependenciesWith(t *testing.T) {
+	g := buildGraph()
+	g.AddVertex("G", "", "database", true)
+	g.AddEdge("E", "G")
+	g.AddEdge("A", "D")
+
+	tr, err := g.VertexDependenciesWith("A", graphlib.TraversalOptions{MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := keys(tr.Vertices); !reflect.DeepEqual(got, []string{"A", "B", "C", "D", "E"}) {
+		t.Fatalf("unexpected vertices %v", got)
+	}
+	if want := map[string]int{"A": 0, "B": 1, "C": 1, "D": 1, "E": 2}; !reflect.DeepEqual(tr.Depth, want) {
+		t.Fatalf("want depths %v, got %v", want, tr.Depth)
+	}
+	// C → D is walked, D → E too, E → G is past the limit
+	wantE := map[e]bool{{"A", "B"}: true, {"A", "C"}: true, {"A", "D"}: true, {"C", "D"}: true, {"D", "E"}: true}
+	if got := setEdges(tr.Edges); !reflect.DeepEqual(got, wantE) {
+		t.Fatalf("want edges %v, got %v", wantE, got)
+	}
+
+	// unlimited matches VertexDependencies(key, true)
+	tr, _ = g.VertexDependenciesWith("A", graphlib.TraversalOptions{})
+	sg, _ := g.VertexDependencies("A", true)
+	if !reflect.DeepEqual(setVerts(tr.Vertices), setVerts(sg.Vertices)) || !reflect.DeepEqual(setEdges(tr.Edges), setEdges(sg.Edges)) {
+		t.Fatalf("expected %v, got %v", sg, tr.Subgraph)
+	}
+	if tr.Depth["G"] != 3 {
+		t.Fatalf("expected G at depth 3, got %v", tr.Depth)
+	}
+}
+
+func TestVertexDependenciesWith_Filters(t *testing.T) {
+	g := buildGraph()
+	g.AddVertex("G", "", "database", true)
+	g.AddEdge("E", "G")
+	g.SetVertexStatus("G", graphlib.StatusDegraded)
+
+	// the filter keeps G although it is reached through servers
+	tr, err := g.VertexDependenciesWith("A", graphlib.TraversalOptions{ClassFilter: []string{"database"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := keys(tr.Vertices); !reflect.DeepEqual(got, []string{"A", "G"}) {
+		t.Fatalf("unexpected vertices %v", got)
+	}
+	if len(tr.Edges) != 0 || tr.Depth["G"] != 4 {
+		t.Fatalf("unexpected traversal %+v", tr)
+	}
+
+	tr, _ = g.VertexDependentsWith("G", graphlib.TraversalOptions{HealthFilter: []graphlib.HealthStatus{graphlib.StatusHealthy}})
+	if got := keys(tr.Vertices); !reflect.DeepEqual(got, []string{"G"}) {
+		t.Fatalf("expected only the root, got %v", got)
+	}
+
+	tr, _ = g.VertexDependentsWith("G", graphlib.TraversalOptions{Limit: 3})
+	if got := keys(tr.Vertices); !reflect.DeepEqual(got, []string{"G", "E", "D"}) {
+		t.Fatalf("unexpected vertices %v", got)
+	}
+	wantE := map[e]bool{{"E", "G"}: true, {"D", "E"}: true}
+	if got := setEdges(tr.Edges); !reflect.DeepEqual(got, wantE) {
+		t.Fatalf("want edges %v, got %v", wantE, got)
+	}
+
+	var nf graphlib.VertexNotFoundErr
+	if _, err := g.VertexDependentsWith("X", graphlib.TraversalOptions{}); !errors.As(err, &nf) {
+		t.Fatalf("expected VertexNotFoundErr, got %v", err)
+	}
+}
@@ -0,0 +1,526 @@
+package graphlib
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// WALSync decides when appended records are flushed to stable storage.
+type WALSync uint8
+
+const (
+	// WALSyncAlways calls fsync after every record.
+	WALSyncAlways WALSync = iota
+	// WALSyncInterval calls fsync every WALOptions.SyncInterval, or every
+	// second when it is zero.
+	WALSyncInterval
+	// WALSyncNever leaves flushing to the operating system.
+	WALSyncNever
+)
+
+type WALOptions struct {
+	Sync         WALSync
+	SyncInterval time.Duration
+
+	// CheckpointPath is where Compact writes the snapshot the log is replayed
+	// on. It defaults to the log path with a ".checkpoint" suffix.
+	CheckpointPath string
+	// CompactInterval runs Compact periodically. Zero disables it.
+	CompactInterval time.Duration
+}
+
+type walOp uint8
+
+const (
+	opAddVertex walOp = iota + 1
+	opAddEdge
+	opRemoveEdge
+	opReplaceEdges
+	opRemoveVertex
+	opSetStatus
+	opClearHealth
+)
+
+// walFields is the number of fields logged with each op. ReplaceEdges logs
+// its source followed by any number of targets.
+var walFields = map[walOp]int{
+	opAddVertex:    4,
+	opAddEdge:      2,
+	opRemoveEdge:   2,
+	opRemoveVertex: 1,
+	opSetStatus:    2,
+	opClearHealth:  0,
+}
+
+// A checkpoint is a binary snapshot prefixed with its magic and the
+// sequence number of the last record it contains. Log records are framed
+// as a uint32 payload length and a uint32 crc32 of the payload, followed by
+// the payload: uvarint seq, op byte, varint timestamp, uvarint field count
+// and the fields as strings.
+const (
+	checkpointMagic = "GLCP"
+	frameHeader     = 8
+	maxRecordSize   = 64 << 20
+
+	defaultSyncInterval = time.Second
+)
+
+type wal struct {
+	f    *os.File
+	path string
+	opts WALOptions
+	seq  uint64
+	buf  []byte
+
+	mu    sync.Mutex // guards dirty and err against the sync goroutine
+	dirty bool
+	err   error
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// OpenGraph restores a graph from its latest checkpoint and the write-ahead
+// log at path, then keeps appending every AddVertex, AddEdge, RemoveEdge,
+// ReplaceEdges, RemoveVertex, SetVertexStatus and ClearHealthyStatus to the
+// log. A torn record at the end of the log, left by a crash, is discarded.
+//
+// A mutation is applied in memory before it is logged. When the record
+// cannot be written, or synced with WALSyncAlways, the methods returning an
+// error report a WALErr: the change is in the graph but may not survive a
+// restart. AddVertex and ClearHealthyStatus have no error to return, their
+// failures are reported by CloseWAL.
+//
+// Configuration such as propagation rules, policies and TTLs is not logged:
+// apply it again after opening. The stale sweeper is not logged either, it
+// marks the same vertices again once it runs.
+func OpenGraph(path string, opts WALOptions, logger *slog.Logger) (*Graph, error) {
+	if opts.CheckpointPath == "" {
+		opts.CheckpointPath = path + ".checkpoint"
+	}
+	if opts.Sync == WALSyncInterval && opts.SyncInterval <= 0 {
+		opts.SyncInterval = defaultSyncInterval
+	}
+
+	g := NewSoAGraph(logger)
+	g.logger.Debug("core.OpenGraph", slog.String("path", path), slog.String("checkpoint", opts.CheckpointPath))
+
+	seq, err := g.loadCheckpoint(opts.CheckpointPath)
+	if err != nil {
+		g.logger.Error("core.OpenGraph checkpoint error", slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := g.replay(f, seq)
+	if err != nil {
+		g.logger.Error("core.OpenGraph replay error", slog.String("err", err.Error()))
+		f.Close()
+		return nil, err
+	}
+
+	g.resetChanges()
+
+	g.wal = &wal{f: f, path: path, opts: opts, seq: max(seq, last), done: make(chan struct{})}
+	g.startWAL()
+
+	return g, nil
+}
+
+// Compact writes a fresh checkpoint of the graph and empties the log.
+func (g *Graph) Compact() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger.Debug("core.Graph.Compact")
+
+	if g.wal == nil {
+		return WALErr{Reason: "no write-ahead log attached"}
+	}
+
+	return g.compact()
+}
+
+// CloseWAL stops the background goroutines, syncs and closes the log. The
+// graph keeps working in memory only.
+func (g *Graph) CloseWAL() error {
+	g.mu.Lock()
+	w := g.wal
+	g.wal = nil
+	g.mu.Unlock()
+
+	if w == nil {
+		return nil
+	}
+
+	g.logger.Debug("core.Graph.CloseWAL", slog.String("path", w.path))
+
+	close(w.done)
+	w.wg.Wait()
+
+	w.mu.Lock()
+	err := w.err
+	w.mu.Unlock()
+
+	if serr := w.f.Sync(); err == nil {
+		err = serr
+	}
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// logOp appends a record for a successful mutation and returns a WALErr
+// when it did not reach the log. Failures are also kept, to be reported by
+// CloseWAL, since the mutation already happened.
+func (g *Graph) logOp(op walOp, fields ...string) error {
+	w := g.wal
+	if w == nil {
+		return nil
+	}
+
+	// lastCheck is the only clock reading kept in the graph, log the one the
+	// operation stored so replay reproduces it exactly
+	ts := g.nowFn()
+	if op == opAddVertex || op == opSetStatus {
+		ts = g.lastCheck[g.lookup[fields[0]]]
+	}
+
+	w.seq++
+	w.buf = w.buf[:0]
+	w.buf = append(w.buf, make([]byte, frameHeader)...)
+	w.buf = binary.AppendUvarint(w.buf, w.seq)
+	w.buf = append(w.buf, byte(op))
+	w.buf = binary.AppendVarint(w.buf, ts)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(fields)))
+	for _, f := range fields {
+		w.buf = binary.AppendUvarint(w.buf, uint64(len(f)))
+		w.buf = append(w.buf, f...)
+	}
+
+	payload := w.buf[frameHeader:]
+	binary.LittleEndian.PutUint32(w.buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(w.buf[4:8], crc32.ChecksumIEEE(payload))
+
+	_, err := w.f.Write(w.buf)
+	if err == nil && w.opts.Sync == WALSyncAlways {
+		err = w.f.Sync()
+	}
+
+	w.mu.Lock()
+	w.dirty = true
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.mu.Unlock()
+
+	if err != nil {
+		g.logger.Error("core.Graph.logOp write error", slog.Uint64("seq", w.seq), slog.String("err", err.Error()))
+		return WALErr{Reason: fmt.Sprintf("record %d: %v", w.seq, err)}
+	}
+
+	return nil
+}
+
+func (g *Graph) startWAL() {
+	w := g.wal
+
+	if w.opts.Sync == WALSyncInterval {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			ticker := time.NewTicker(w.opts.SyncInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-w.done:
+					return
+				case <-ticker.C:
+					w.mu.Lock()
+					if w.dirty {
+						if err := w.f.Sync(); err != nil && w.err == nil {
+							w.err = err
+						}
+						w.dirty = false
+					}
+					w.mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	if w.opts.CompactInterval > 0 {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			ticker := time.NewTicker(w.opts.CompactInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-w.done:
+					return
+				case <-ticker.C:
+					g.mu.Lock()
+					if g.wal == w {
+						if err := g.compact(); err != nil {
+							g.logger.Error("core.Graph.compact periodic compaction error", slog.String("err", err.Error()))
+						}
+					}
+					g.mu.Unlock()
+				}
+			}
+		}()
+	}
+}
+
+// compact writes the checkpoint next to its final path, syncs it and renames
+// it over the previous one before truncating the log. A crash in between
+// leaves records the checkpoint already contains, which replay skips by
+// sequence number.
+func (g *Graph) compact() error {
+	w := g.wal
+	tmp := w.opts.CheckpointPath + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	header := append([]byte(checkpointMagic), binary.LittleEndian.AppendUint64(nil, w.seq)...)
+	if _, err = f.Write(header); err == nil {
+		err = g.writeBinarySnapshot(f)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, w.opts.CheckpointPath)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		g.logger.Error("core.Graph.compact checkpoint error", slog.String("err", err.Error()))
+		return err
+	}
+
+	if err := w.f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.dirty = false
+	w.mu.Unlock()
+
+	g.logger.Info("core.Graph.compact log compacted", slog.Uint64("seq", w.seq), slog.Int("vertices", len(g.labels)))
+
+	return w.f.Sync()
+}
+
+// loadCheckpoint restores the checkpoint at path, if any, and returns the
+// sequence number of the last record it contains.
+func (g *Graph) loadCheckpoint(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var header [len(checkpointMagic) + 8]byte
+	if _, err := io.ReadFull(f, header[:]); err != nil || string(header[:len(checkpointMagic)]) != checkpointMagic {
+		return 0, SnapshotErr{Reason: "bad checkpoint header"}
+	}
+
+	if err := g.restoreBinary(f); err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint64(header[len(checkpointMagic):]), nil
+}
+
+// replay applies the records of the log after seq and returns the sequence
+// number of the last record found. A short or corrupt final record is what
+// a crash during a write leaves behind: it is discarded and the log is
+// truncated after the last complete record. A corrupt record followed by
+// more data is a WALErr, and the log is left as it is.
+func (g *Graph) replay(f *os.File, seq uint64) (uint64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	end := info.Size()
+
+	r := bufio.NewReader(f)
+	now := g.nowFn
+	defer func() { g.nowFn = now }()
+
+	var offset int64
+	last := seq
+	var header [frameHeader]byte
+
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if err != io.EOF {
+				g.logger.Warn("core.Graph.replay torn record header discarded", slog.Int64("offset", offset))
+			}
+			break
+		}
+
+		size := binary.LittleEndian.Uint32(header[0:4])
+		next := offset + frameHeader + int64(size)
+
+		var payload []byte
+		bad := ""
+		if size > maxRecordSize {
+			bad = "oversized record"
+		} else {
+			payload = make([]byte, size)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				bad = "torn record"
+			} else if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+				bad = "checksum mismatch"
+			}
+		}
+
+		if bad != "" {
+			if next < end {
+				err := WALErr{Reason: fmt.Sprintf("%s at offset %d with %d bytes after it", bad, offset, end-next)}
+				g.logger.Error("core.Graph.replay corrupt record", slog.Int64("offset", offset), slog.String("err", err.Error()))
+				return 0, err
+			}
+			g.logger.Warn("core.Graph.replay torn tail discarded", slog.Int64("offset", offset), slog.String("reason", bad))
+			break
+		}
+
+		rec, err := decodeRecord(payload)
+		if err != nil {
+			return 0, WALErr{Reason: fmt.Sprintf("record at offset %d: %v", offset, err)}
+		}
+
+		if rec.seq > seq {
+			g.nowFn = func() int64 { return rec.ts }
+			if err := g.apply(rec); err != nil {
+				return 0, WALErr{Reason: fmt.Sprintf("record at offset %d: %v", offset, err)}
+			}
+		}
+
+		last = max(last, rec.seq)
+		offset = next
+	}
+
+	if err := f.Truncate(offset); err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	return last, nil
+}
+
+type walRecord struct {
+	seq    uint64
+	op     walOp
+	ts     int64
+	fields []string
+}
+
+func decodeRecord(payload []byte) (walRecord, error) {
+	var rec walRecord
+	var n int
+
+	rec.seq, n = binary.Uvarint(payload)
+	if n <= 0 || n >= len(payload) {
+		return rec, errors.New("bad sequence number")
+	}
+	payload = payload[n:]
+
+	rec.op = walOp(payload[0])
+	payload = payload[1:]
+
+	rec.ts, n = binary.Varint(payload)
+	if n <= 0 {
+		return rec, errors.New("bad timestamp")
+	}
+	payload = payload[n:]
+
+	count, n := binary.Uvarint(payload)
+	if n <= 0 || count > uint64(len(payload)) {
+		return rec, errors.New("bad field count")
+	}
+	payload = payload[n:]
+
+	rec.fields = make([]string, 0, count)
+	for i := uint64(0); i < count; i++ {
+		size, n := binary.Uvarint(payload)
+		if n <= 0 || size > uint64(len(payload)-n) {
+			return rec, errors.New("bad field")
+		}
+		rec.fields = append(rec.fields, string(payload[n:n+int(size)]))
+		payload = payload[n+int(size):]
+	}
+
+	return rec, nil
+}
+
+// apply replays a record through the same code as the public method that
+// logged it.
+func (g *Graph) apply(rec walRecord) error {
+	if n, ok := walFields[rec.op]; (ok && len(rec.fields) != n) || (rec.op == opReplaceEdges && len(rec.fields) == 0) {
+		return fmt.Errorf("record %d: op %d with %d fields", rec.seq, rec.op, len(rec.fields))
+	}
+
+	f := rec.fields
+	switch rec.op {
+	case opAddVertex:
+		status, err := ParseHealthStatus(f[3])
+		if err != nil {
+			return err
+		}
+		g.addVertex(f[0], f[1], f[2], status)
+		return nil
+	case opAddEdge:
+		return g.addEdge(f[0], f[1])
+	case opRemoveEdge:
+		return g.removeEdge(f[0], f[1])
+	case opReplaceEdges:
+		return g.replaceEdges(f[0], f[1:])
+	case opRemoveVertex:
+		return g.removeVertex(f[0])
+	case opSetStatus:
+		status, err := ParseHealthStatus(f[1])
+		if err != nil {
+			return err
+		}
+		return g.setVertexStatus(f[0], status)
+	case opClearHealth:
+		g.clearHealth()
+		return nil
+	}
+
+	return fmt.Errorf("record %d: unknown op %d", rec.seq, rec.op)
+}
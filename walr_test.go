@@ -0,0 +1,183 @@
+package graphlib_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func openWAL(t *testing.T, path string, opts graphlib.WALOptions) *graphlib.Graph {
+	t.Helper()
+
+	g, err := graphlib.OpenGraph(path, opts, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return g
+}
+
+func snapshotOf(t *testing.T, g *graphlib.Graph) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := g.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buf.String()
+}
+
+// mutateWAL runs every logged operation at least once.
+func mutateWAL(g *graphlib.Graph) {
+	g.AddVertex("A", "a", "service", true)
+	g.AddVertex("B", "b", "service", true)
+	g.AddVertex("C", "c", "database", true)
+	g.AddVertex("D", "d", "database", false)
+	g.AddEdge("A", "B")
+	g.AddEdge("B", "C")
+	g.AddEdge("A", "D")
+	g.SetVertexStatus("C", graphlib.StatusDegraded)
+	g.RemoveEdge("A", "D")
+	g.ReplaceEdges("B", []string{"D"})
+	g.RemoveVertex("C")
+	g.ClearHealthyStatus()
+	g.SetVertexHealth("D", false)
+}
+
+func TestWALReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.wal")
+
+	g := openWAL(t, path, graphlib.WALOptions{})
+	mutateWAL(g)
+	want := snapshotOf(t, g)
+	if err := g.CloseWAL(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// background syncs and compactions must not change what is replayed
+	loaded := openWAL(t, path, graphlib.WALOptions{Sync: graphlib.WALSyncInterval, SyncInterval: time.Millisecond, CompactInterval: 5 * time.Millisecond})
+	defer loaded.CloseWAL()
+
+	if got := snapshotOf(t, loaded); got != want {
+		t.Fatalf("replayed graph differs:\n%s\n%s", got, want)
+	}
+
+	// the reopened log keeps recording
+	loaded.AddEdge("A", "B")
+	time.Sleep(20 * time.Millisecond)
+	loaded.SetVertexHealth("D", true)
+	want = snapshotOf(t, loaded)
+	loaded.CloseWAL()
+
+	again := openWAL(t, path, graphlib.WALOptions{})
+	defer again.CloseWAL()
+	if got := snapshotOf(t, again); got != want {
+		t.Fatalf("replayed graph differs:\n%s\n%s", got, want)
+	}
+}
+
+func TestWALTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.wal")
+
+	g := openWAL(t, path, graphlib.WALOptions{})
+	mutateWAL(g)
+	want := snapshotOf(t, g)
+	g.CloseWAL()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a crash in the middle of a write leaves a partial record
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.Write([]byte{40, 0, 0, 0, 1, 2, 3, 4, 5})
+	f.Close()
+
+	loaded := openWAL(t, path, graphlib.WALOptions{})
+	defer loaded.CloseWAL()
+
+	if got := snapshotOf(t, loaded); got != want {
+		t.Fatalf("replayed graph differs:\n%s\n%s", got, want)
+	}
+
+	if now, _ := os.Stat(path); now.Size() != info.Size() {
+		t.Fatalf("expected torn tail to be truncated to %d bytes, got %d", info.Size(), now.Size())
+	}
+}
+
+func TestWALCorruptRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.wal")
+
+	g := openWAL(t, path, graphlib.WALOptions{})
+	mutateWAL(g)
+	g.CloseWAL()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a bad checksum on the first record, with valid records after it
+	corrupt := bytes.Clone(data)
+	corrupt[8] ^= 0xff
+	os.WriteFile(path, corrupt, 0o644)
+
+	_, err = graphlib.OpenGraph(path, graphlib.WALOptions{}, nil)
+	var wErr graphlib.WALErr
+	if !errors.As(err, &wErr) {
+		t.Fatalf("expected WALErr, got %v", err)
+	}
+	if now, _ := os.ReadFile(path); !bytes.Equal(now, corrupt) {
+		t.Fatalf("expected the log to be left untouched")
+	}
+
+	// the same damage on the last record is a torn tail
+	corrupt = bytes.Clone(data)
+	corrupt[len(corrupt)-1] ^= 0xff
+	os.WriteFile(path, corrupt, 0o644)
+
+	loaded := openWAL(t, path, graphlib.WALOptions{})
+	defer loaded.CloseWAL()
+
+	if info, _ := os.Stat(path); info.Size() >= int64(len(data)) {
+		t.Fatalf("expected the last record to be discarded, got %d of %d bytes", info.Size(), len(data))
+	}
+}
+
+func TestWALCompact(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "graph.wal")
+	opts := graphlib.WALOptions{CheckpointPath: filepath.Join(dir, "graph.ckpt")}
+
+	g := openWAL(t, path, opts)
+	mutateWAL(g)
+
+	if err := g.Compact(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info, _ := os.Stat(path); info.Size() != 0 {
+		t.Fatalf("expected empty log after compaction, got %d bytes", info.Size())
+	}
+
+	g.AddVertex("E", "e", "cache", true)
+	g.AddEdge("D", "E")
+	g.SetVertexStatus("E", graphlib.StatusMaintenance)
+	want := snapshotOf(t, g)
+	g.CloseWAL()
+
+	loaded := openWAL(t, path, opts)
+	defer loaded.CloseWAL()
+
+	if got := snapshotOf(t, loaded); got != want {
+		t.Fatalf("replayed graph differs:\n%s\n%s", got, want)
+	}
+
+	if err := graphlib.NewSoAGraph(nil).Compact(); err == nil {
+		t.Fatalf("expected error compacting a graph without a log")
+	}
+}
@@ -0,0 +1,138 @@
+package graphlib
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+type cytoscapeElements struct {
+	Nodes []cytoscapeNode `json:"nodes"`
+	Edges []cytoscapeEdge `json:"edges"`
+}
+
+type cytoscapeNode struct {
+	Data    webNode `json:"data"`
+	Classes string  `json:"classes"`
+}
+
+type cytoscapeEdge struct {
+	Data    webLink `json:"data"`
+	Classes string  `json:"classes"`
+}
+
+type d3Graph struct {
+	Nodes []d3Node  `json:"nodes"`
+	Links []webLink `json:"links"`
+}
+
+type d3Node struct {
+	webNode
+	Classes string `json:"classes"`
+}
+
+type webNode struct {
+	ID         string       `json:"id"`
+	Label      string       `json:"label"`
+	Class      string       `json:"class"`
+	Status     HealthStatus `json:"status"`
+	OwnStatus  HealthStatus `json:"own_status"`
+	ImpactedBy []string     `json:"impacted_by,omitempty"`
+	LastCheck  int64        `json:"last_check"`
+}
+
+type webLink struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// WriteCytoscape writes sg as the Cytoscape.js elements JSON. Vertex fields
+// go in data, and the classes of each node are its status, "inherited" when
+// that status comes from its dependencies, and "class-" followed by its
+// class. Edges point from a vertex to its dependency, are classed like
+// their source and have the JSON array [source, target] as id.
+func WriteCytoscape(w io.Writer, sg Subgraph) error {
+	sg = sorted(sg)
+
+	out := cytoscapeElements{
+		Nodes: make([]cytoscapeNode, len(sg.Vertices)),
+		Edges: make([]cytoscapeEdge, len(sg.Edges)),
+	}
+
+	status := make(map[string]HealthStatus, len(sg.Vertices))
+	for i, v := range sg.Vertices {
+		out.Nodes[i] = cytoscapeNode{Data: newWebNode(v), Classes: webClasses(v)}
+		status[v.Key] = v.Status
+	}
+
+	for i, e := range sg.Edges {
+		out.Edges[i] = cytoscapeEdge{Data: newWebLink(e)}
+		if s, ok := status[e.Source]; ok {
+			out.Edges[i].Classes = s.String()
+		}
+	}
+
+	return json.NewEncoder(w).Encode(out)
+}
+
+// WriteD3 writes sg as the nodes and links JSON used by D3 force layouts,
+// where links reference nodes by id. Nodes carry the same fields and
+// classes as WriteCytoscape.
+func WriteD3(w io.Writer, sg Subgraph) error {
+	sg = sorted(sg)
+
+	out := d3Graph{
+		Nodes: make([]d3Node, len(sg.Vertices)),
+		Links: make([]webLink, len(sg.Edges)),
+	}
+
+	for i, v := range sg.Vertices {
+		out.Nodes[i] = d3Node{webNode: newWebNode(v), Classes: webClasses(v)}
+	}
+	for i, e := range sg.Edges {
+		out.Links[i] = newWebLink(e)
+	}
+
+	return json.NewEncoder(w).Encode(out)
+}
+
+func newWebNode(v Vertex) webNode {
+	return webNode{
+		ID:         v.Key,
+		Label:      vertexLabel(v),
+		Class:      v.Class,
+		Status:     v.Status,
+		OwnStatus:  v.OwnStatus,
+		ImpactedBy: v.ImpactedBy,
+		LastCheck:  v.LastCheck,
+	}
+}
+
+// newWebLink identifies e by the JSON array of its ends rather than by its
+// key, which is ambiguous when keys contain dashes.
+func newWebLink(e Edge) webLink {
+	id, _ := json.Marshal([2]string{e.Source, e.Target})
+	return webLink{ID: string(id), Source: e.Source, Target: e.Target}
+}
+
+func webClasses(v Vertex) string {
+	classes := []string{v.Status.String()}
+	if v.Status != v.OwnStatus {
+		classes = append(classes, "inherited")
+	}
+	classes = append(classes, "class-"+cssIdent(v.Class))
+
+	return strings.Join(classes, " ")
+}
+
+// cssIdent replaces the characters not allowed in a CSS class name with
+// dashes.
+func cssIdent(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, s)
+}
@@ -0,0 +1,148 @@
+package graphlib_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/opsminded/graphlib/v2"
+)
+
+func TestWriteCytoscape(t *testing.T) {
+	g := buildGraph()
+	g.AddVertex("G", "gee", "data base", true)
+	g.AddEdge("E", "G")
+	g.SetVertexStatus("G", graphlib.StatusUnhealthy)
+
+	sg, err := g.VertexDependents("G", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := graphlib.WriteCytoscape(&buf, sg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out struct {
+		Nodes []struct {
+			Data    map[string]any `json:"data"`
+			Classes string         `json:"classes"`
+		} `json:"nodes"`
+		Edges []struct {
+			Data    map[string]string `json:"data"`
+			Classes string            `json:"classes"`
+		} `json:"edges"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(out.Nodes) != 6 || len(out.Edges) != 5 {
+		t.Fatalf("expected 6 nodes and 5 edges, got %d and %d", len(out.Nodes), len(out.Edges))
+	}
+
+	a := out.Nodes[0]
+	if a.Data["id"] != "A" || a.Data["status"] != "unhealthy" || a.Data["own_status"] != "healthy" || a.Classes != "unhealthy inherited class-server" {
+		t.Fatalf("unexpected node %+v", a)
+	}
+	if gv := out.Nodes[5]; gv.Data["id"] != "G" || gv.Data["label"] != "gee" || gv.Classes != "unhealthy class-data-base" {
+		t.Fatalf("unexpected node %+v", gv)
+	}
+
+	if e := out.Edges[0]; e.Data["source"] != "A" || e.Data["target"] != "C" || e.Data["id"] != `["A","C"]` || e.Classes != "unhealthy" {
+		t.Fatalf("unexpected edge %+v", e)
+	}
+}
+
+func TestWriteD3(t *testing.T) {
+	sg, err := buildGraph().Path("A", "E")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := graphlib.WriteD3(&buf, sg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out struct {
+		Nodes []map[string]any    `json:"nodes"`
+		Links []map[string]string `json:"links"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ids []string
+	for _, n := range out.Nodes {
+		ids = append(ids, n["id"].(string))
+		if n["label"] != n["id"] || n["class"] != "server" || n["classes"] != "healthy class-server" {
+			t.Fatalf("unexpected node %v", n)
+		}
+	}
+	if !equalKeys(ids, []string{"A", "C", "D", "E"}) {
+		t.Fatalf("unexpected nodes %v", ids)
+	}
+
+	want := []map[string]string{
+		{"id": `["A","C"]`, "source": "A", "target": "C"},
+		{"id": `["C","D"]`, "source": "C", "target": "D"},
+		{"id": `["D","E"]`, "source": "D", "target": "E"},
+	}
+	if !reflect.DeepEqual(out.Links, want) {
+		t.Fatalf("unexpected links %v", out.Links)
+	}
+}
+
+func TestWriteCytoscape_HyphenatedKeys(t *testing.T) {
+	// web-01 → db and web → 01-db both have the key web-01-db
+	g := graphlib.NewSoAGraph(nil)
+	for _, k := range []string{"web-01", "db", "web", "01-db"} {
+		g.AddVertex(k, "", "server", true)
+	}
+	g.AddEdge("web-01", "db")
+	g.AddEdge("web", "01-db")
+
+	var sg graphlib.Subgraph
+	for _, k := range []string{"web-01", "web"} {
+		deps, _ := g.VertexDependencies(k, false)
+		sg.Vertices = append(sg.Vertices, deps.Vertices...)
+		sg.Edges = append(sg.Edges, deps.Edges...)
+	}
+	if sg.Edges[0].Key != sg.Edges[1].Key {
+		t.Fatalf("expected colliding edge keys, got %q and %q", sg.Edges[0].Key, sg.Edges[1].Key)
+	}
+
+	var buf bytes.Buffer
+	if err := graphlib.WriteCytoscape(&buf, sg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out struct {
+		Nodes []struct {
+			Data map[string]any `json:"data"`
+		} `json:"nodes"`
+		Edges []struct {
+			Data map[string]string `json:"data"`
+		} `json:"edges"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ids := make(map[string]bool)
+	for _, n := range out.Nodes {
+		ids[n.Data["id"].(string)] = true
+	}
+	for _, e := range out.Edges {
+		if ids[e.Data["id"]] {
+			t.Fatalf("duplicate element id %q", e.Data["id"])
+		}
+		ids[e.Data["id"]] = true
+	}
+	if len(ids) != 6 {
+		t.Fatalf("expected 6 distinct ids, got %v", ids)
+	}
+}